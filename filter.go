@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Fuzzy match scoring, loosely after fzf: every matched character scores,
+// with bonuses for word starts and runs and a penalty for each skipped
+// character in between.
+const (
+	scoreMatch       = 16
+	bonusBoundary    = 8
+	bonusConsecutive = 4
+	penaltyGap       = 1
+)
+
+var filterMatchStyle = lipgloss.NewStyle().Foreground(yellow).Bold(true).Underline(true)
+
+// projectMatch is a project shown in a filtered project list.
+type projectMatch struct {
+	index     int // Into m.projects and m.projectPaths
+	score     int
+	positions []int // Rune indexes of the matched characters in the name
+}
+
+// fuzzyMatch reports whether the characters of pattern appear in s in order,
+// ignoring case, and scores the best such match it finds. positions are the
+// rune indexes of the matched characters.
+func fuzzyMatch(pattern, s string) (score int, positions []int, ok bool) {
+	p := []rune(pattern)
+	for i := range p {
+		p[i] = unicode.ToLower(p[i])
+	}
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+	r := []rune(s)
+	lower := make([]rune, len(r))
+	for i := range r {
+		lower[i] = unicode.ToLower(r[i])
+	}
+
+	// Match greedily from every place the first character appears and keep
+	// the best; this finds the word-start and consecutive matches that a
+	// single left-to-right scan skips over
+	for start := range lower {
+		if lower[start] != p[0] {
+			continue
+		}
+		pos := []int{start}
+		for i := start + 1; i < len(lower) && len(pos) < len(p); i++ {
+			if lower[i] == p[len(pos)] {
+				pos = append(pos, i)
+			}
+		}
+		if len(pos) < len(p) {
+			// Later starts have even less of s left to match in
+			break
+		}
+		if sc := scoreMatchPositions(r, pos); !ok || sc > score {
+			score, positions, ok = sc, pos, true
+		}
+	}
+	return score, positions, ok
+}
+
+func scoreMatchPositions(r []rune, positions []int) int {
+	score := 0
+	for k, i := range positions {
+		score += scoreMatch
+		if isWordStart(r, i) {
+			score += bonusBoundary
+		}
+		if k > 0 {
+			if gap := i - positions[k-1] - 1; gap == 0 {
+				score += bonusConsecutive
+			} else {
+				score -= gap * penaltyGap
+			}
+		}
+	}
+	return score
+}
+
+// isWordStart reports whether r[i] starts a word: the start of the name, a
+// path or separator boundary such as the "w" in "app/web", or a camelCase
+// hump.
+func isWordStart(r []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := r[i-1]
+	return strings.ContainsRune("/-_. ", prev) || (unicode.IsLower(prev) && unicode.IsUpper(r[i]))
+}
+
+// filteredProjects returns the projects matching m.projectFilter, best match
+// first. Without a filter every project is listed in order.
+func (m model) filteredProjects() []projectMatch {
+	var matches []projectMatch
+	for i, name := range m.projects {
+		if score, positions, ok := fuzzyMatch(m.projectFilter, name); ok {
+			matches = append(matches, projectMatch{index: i, score: score, positions: positions})
+		}
+	}
+	if m.projectFilter != "" {
+		sort.SliceStable(matches, func(a, b int) bool {
+			if matches[a].score != matches[b].score {
+				return matches[a].score > matches[b].score
+			}
+			// Like fzf, prefer the shorter name on a tie
+			return len(m.projects[matches[a].index]) < len(m.projects[matches[b].index])
+		})
+	}
+	return matches
+}
+
+// projectItems returns the names of the projects in a project list.
+func (m model) projectItems() []string {
+	var items []string
+	for _, match := range m.filteredProjects() {
+		items = append(items, m.projects[match.index])
+	}
+	return items
+}
+
+// updateProjectFilter edits the filter of a project list: typing narrows the
+// list, backspace widens it again and esc clears it. Digits select by number
+// until a filter has been typed. It reports whether the key was used.
+func (m model) updateProjectFilter(msg tea.KeyMsg) (model, bool) {
+	switch msg.Type {
+	case tea.KeyRunes:
+		if m.projectFilter == "" && unicode.IsDigit(msg.Runes[0]) {
+			return m, false
+		}
+		m.projectFilter += string(msg.Runes)
+	case tea.KeyBackspace:
+		if m.projectFilter == "" {
+			return m, false
+		}
+		r := []rune(m.projectFilter)
+		m.projectFilter = string(r[:len(r)-1])
+	case tea.KeyEsc, tea.KeyCtrlU:
+		if m.projectFilter == "" {
+			return m, false
+		}
+		m.projectFilter = ""
+	default:
+		return m, false
+	}
+	m.cursor = 0
+	return m, true
+}
+
+// renderProjectFilter renders the filter line above a filtered project list.
+func (m model) renderProjectFilter() string {
+	shown := len(m.filteredProjects())
+	line := cursorStyle.Render("/ ") + selectedStyle.Render(m.projectFilter) +
+		dimStyle.Render(fmt.Sprintf("  %d of %d", shown, len(m.projects)))
+	if shown == 0 {
+		line += "\n\n" + dimStyle.Render("  No projects match • backspace to edit, esc to clear")
+	}
+	return line
+}
+
+// highlightMatches renders name with the characters at positions picked out
+// and the first dimmed characters, such as a root prefix, dimmed.
+func highlightMatches(name string, positions []int, dimmed int, style lipgloss.Style) string {
+	if len(positions) == 0 && dimmed == 0 {
+		return style.Render(name)
+	}
+	var s strings.Builder
+	next := 0
+	for i, r := range []rune(name) {
+		switch {
+		case next < len(positions) && positions[next] == i:
+			s.WriteString(filterMatchStyle.Render(string(r)))
+			next++
+		case i < dimmed:
+			s.WriteString(dimStyle.Render(string(r)))
+		default:
+			s.WriteString(style.Render(string(r)))
+		}
+	}
+	return s.String()
+}
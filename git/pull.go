@@ -0,0 +1,146 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pull modes, the ways Pull integrates upstream commits.
+const (
+	FastForward = "ff-only"
+	Rebase      = "rebase"
+	Merge       = "merge"
+)
+
+// Outcome is what Pull did to a repository.
+type Outcome int
+
+const (
+	Updated Outcome = iota
+	WouldUpdate
+	UpToDate
+	Skipped
+	Failed
+)
+
+// PullOptions configure Pull.
+type PullOptions struct {
+	Mode   string // FastForward (default), Rebase or Merge
+	DryRun bool   // Fetch only; report what would change without touching the working tree
+}
+
+// PullResult describes the outcome of Pull for one repository.
+type PullResult struct {
+	Outcome  Outcome
+	From, To string   // Abbreviated commit range, for Updated and WouldUpdate
+	Commits  []string // One-line summaries of the incoming commits, newest first
+	Ahead    int      // Local commits not on the upstream
+	Reason   string   // Why the repository was skipped
+	Err      error    // Why the pull failed
+}
+
+// Pull fetches the upstream of the current branch and brings the branch up
+// to date with it. Repositories with a detached HEAD, no upstream or
+// uncommitted changes are skipped rather than touched.
+func Pull(ctx context.Context, dir string, opts PullOptions) PullResult {
+	s, err := GetStatus(ctx, dir)
+	switch {
+	case err != nil:
+		return PullResult{Outcome: Failed, Err: err}
+	case s.Detached:
+		return PullResult{Outcome: Skipped, Reason: "detached HEAD"}
+	case s.Upstream == "":
+		return PullResult{Outcome: Skipped, Reason: fmt.Sprintf("branch %s has no upstream", s.Branch)}
+	case s.Conflicted > 0:
+		return PullResult{Outcome: Skipped, Reason: "unresolved conflicts"}
+	case s.Staged > 0 || s.Modified > 0:
+		return PullResult{Outcome: Skipped, Reason: "uncommitted changes"}
+	}
+
+	if _, err := run(ctx, dir, "fetch", "--quiet"); err != nil {
+		return PullResult{Outcome: Failed, Err: err}
+	}
+
+	ahead, behind, err := aheadBehind(ctx, dir)
+	if err != nil {
+		return PullResult{Outcome: Failed, Err: err}
+	}
+	if behind == 0 {
+		return PullResult{Outcome: UpToDate, Ahead: ahead}
+	}
+	mode := opts.Mode
+	if mode == "" {
+		mode = FastForward
+	}
+	if mode == FastForward && ahead > 0 {
+		return PullResult{
+			Outcome: Skipped,
+			Ahead:   ahead,
+			Reason:  fmt.Sprintf("diverged from %s (↑%d ↓%d), can't fast-forward", s.Upstream, ahead, behind),
+		}
+	}
+
+	r := PullResult{Outcome: WouldUpdate, Ahead: ahead}
+	if r.From, err = revParse(ctx, dir, "HEAD"); err != nil {
+		return PullResult{Outcome: Failed, Err: err}
+	}
+	if r.To, err = revParse(ctx, dir, "@{upstream}"); err != nil {
+		return PullResult{Outcome: Failed, Err: err}
+	}
+	if out, err := run(ctx, dir, "log", "--format=%h %s", "HEAD..@{upstream}"); err == nil {
+		r.Commits = strings.Split(strings.TrimSpace(string(out)), "\n")
+	}
+	if opts.DryRun {
+		return r
+	}
+
+	switch mode {
+	case Rebase:
+		if _, err := run(ctx, dir, "rebase", "@{upstream}"); err != nil {
+			run(context.WithoutCancel(ctx), dir, "rebase", "--abort")
+			return PullResult{Outcome: Failed, Err: err}
+		}
+	case Merge:
+		if _, err := run(ctx, dir, "merge", "--no-edit", "@{upstream}"); err != nil {
+			run(context.WithoutCancel(ctx), dir, "merge", "--abort")
+			return PullResult{Outcome: Failed, Err: err}
+		}
+	default:
+		if _, err := run(ctx, dir, "merge", "--ff-only", "@{upstream}"); err != nil {
+			return PullResult{Outcome: Failed, Err: err}
+		}
+	}
+	if r.To, err = revParse(ctx, dir, "HEAD"); err != nil {
+		return PullResult{Outcome: Failed, Err: err}
+	}
+	r.Outcome = Updated
+	return r
+}
+
+// aheadBehind counts the commits on HEAD but not its upstream, and the
+// other way around.
+func aheadBehind(ctx context.Context, dir string) (ahead, behind int, err error) {
+	out, err := run(ctx, dir, "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected git rev-list output %q", out)
+	}
+	if ahead, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, err
+	}
+	if behind, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, err
+	}
+	return ahead, behind, nil
+}
+
+// revParse returns the abbreviated commit hash of rev.
+func revParse(ctx context.Context, dir, rev string) (string, error) {
+	out, err := run(ctx, dir, "rev-parse", "--short", rev)
+	return strings.TrimSpace(string(out)), err
+}
@@ -0,0 +1,200 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runGit runs git in dir for a test and returns its trimmed output.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commit commits a change to file in the clone dir.
+func commit(t *testing.T, dir, file, message string) {
+	t.Helper()
+	f, err := os.OpenFile(filepath.Join(dir, file), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(message + "\n")
+	f.Close()
+	runGit(t, dir, "add", file)
+	runGit(t, dir, "commit", "--quiet", "-m", message)
+}
+
+// remoteRepos returns two clones of a new bare repository with one commit
+// on main: local, the one pulled into, and other, pushing to the remote
+// meanwhile.
+func remoteRepos(t *testing.T) (local, other string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// Keep the user's configuration out of the repositories
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, v := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(v, "Test")
+	}
+	for _, v := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(v, "test@example.com")
+	}
+
+	root := t.TempDir()
+	runGit(t, root, "init", "--quiet", "--bare", "--initial-branch=main", "remote.git")
+	other = filepath.Join(root, "other")
+	runGit(t, root, "clone", "--quiet", "remote.git", other)
+	commit(t, other, "README", "Initial commit")
+	runGit(t, other, "push", "--quiet", "origin", "HEAD:main")
+	local = filepath.Join(root, "local")
+	runGit(t, root, "clone", "--quiet", "remote.git", local)
+	return local, other
+}
+
+func TestPullSkips(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T, dir string)
+		reason string
+	}{
+		{
+			name: "dirty",
+			setup: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "README"), []byte("edited\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			reason: "uncommitted changes",
+		},
+		{
+			name: "staged",
+			setup: func(t *testing.T, dir string) {
+				os.WriteFile(filepath.Join(dir, "new"), []byte("new\n"), 0o644)
+				runGit(t, dir, "add", "new")
+			},
+			reason: "uncommitted changes",
+		},
+		{
+			name:   "no upstream",
+			setup:  func(t *testing.T, dir string) { runGit(t, dir, "switch", "--quiet", "-c", "spike") },
+			reason: "branch spike has no upstream",
+		},
+		{
+			name:   "detached",
+			setup:  func(t *testing.T, dir string) { runGit(t, dir, "switch", "--quiet", "--detach") },
+			reason: "detached HEAD",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, other := remoteRepos(t)
+			commit(t, other, "README", "Upstream change")
+			runGit(t, other, "push", "--quiet")
+			tt.setup(t, local)
+			head := runGit(t, local, "rev-parse", "HEAD")
+
+			r := Pull(context.Background(), local, PullOptions{})
+			if r.Outcome != Skipped || r.Reason != tt.reason {
+				t.Errorf("Pull() = %+v, want skipped for %q", r, tt.reason)
+			}
+			if got := runGit(t, local, "rev-parse", "HEAD"); got != head {
+				t.Errorf("HEAD moved from %s to %s", head, got)
+			}
+		})
+	}
+}
+
+func TestPullFastForward(t *testing.T) {
+	local, other := remoteRepos(t)
+	ctx := context.Background()
+
+	// Untracked files don't keep a repository from being pulled
+	os.WriteFile(filepath.Join(local, "notes"), []byte("notes\n"), 0o644)
+	if r := Pull(ctx, local, PullOptions{}); r.Outcome != UpToDate {
+		t.Fatalf("Pull() without upstream commits = %+v, want up to date", r)
+	}
+
+	commit(t, other, "README", "Second")
+	commit(t, other, "README", "Third")
+	runGit(t, other, "push", "--quiet")
+	head := runGit(t, local, "rev-parse", "--short", "HEAD")
+	upstream := runGit(t, other, "rev-parse", "--short", "HEAD")
+
+	r := Pull(ctx, local, PullOptions{DryRun: true})
+	if r.Outcome != WouldUpdate || r.From != head || r.To != upstream {
+		t.Errorf("dry run = %+v, want %s..%s would update", r, head, upstream)
+	}
+	if len(r.Commits) != 2 || !strings.HasSuffix(r.Commits[0], " Third") || !strings.HasSuffix(r.Commits[1], " Second") {
+		t.Errorf("dry run Commits = %q, want Third and Second", r.Commits)
+	}
+	if got := runGit(t, local, "rev-parse", "--short", "HEAD"); got != head {
+		t.Errorf("dry run moved HEAD from %s to %s", head, got)
+	}
+
+	r = Pull(ctx, local, PullOptions{})
+	if r.Outcome != Updated || r.From != head || r.To != upstream || len(r.Commits) != 2 {
+		t.Errorf("Pull() = %+v, want %s..%s updated with 2 commits", r, head, upstream)
+	}
+	if got := runGit(t, local, "rev-parse", "--short", "HEAD"); got != upstream {
+		t.Errorf("HEAD = %s after Pull, want %s", got, upstream)
+	}
+	if r := Pull(ctx, local, PullOptions{}); r.Outcome != UpToDate {
+		t.Errorf("second Pull() = %+v, want up to date", r)
+	}
+}
+
+func TestPullDiverged(t *testing.T) {
+	tests := []struct {
+		mode    string
+		outcome Outcome
+	}{
+		{FastForward, Skipped},
+		{Rebase, Updated},
+		{Merge, Updated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			local, other := remoteRepos(t)
+			ctx := context.Background()
+			commit(t, other, "upstream.txt", "Upstream change")
+			runGit(t, other, "push", "--quiet")
+			commit(t, local, "local.txt", "Local change")
+			head := runGit(t, local, "rev-parse", "HEAD")
+
+			r := Pull(ctx, local, PullOptions{Mode: tt.mode})
+			if r.Outcome != tt.outcome || r.Ahead != 1 {
+				t.Fatalf("Pull() = %+v, want outcome %d with 1 commit ahead", r, tt.outcome)
+			}
+			if tt.outcome == Skipped {
+				if !strings.Contains(r.Reason, "diverged from origin/main (↑1 ↓1)") {
+					t.Errorf("Reason = %q, want the divergence", r.Reason)
+				}
+				if got := runGit(t, local, "rev-parse", "HEAD"); got != head {
+					t.Errorf("HEAD moved from %s to %s", head, got)
+				}
+				return
+			}
+			// Both changes are on the branch, still ahead of the upstream
+			for _, file := range []string{"upstream.txt", "local.txt"} {
+				if _, err := os.Stat(filepath.Join(local, file)); err != nil {
+					t.Errorf("%s missing after Pull: %v", file, err)
+				}
+			}
+			if ahead, behind, err := aheadBehind(ctx, local); err != nil || behind != 0 || ahead == 0 {
+				t.Errorf("aheadBehind() = %d, %d, %v after Pull, want ahead only", ahead, behind, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a commit shown in a log.
+type Commit struct {
+	Hash    string // Abbreviated
+	Subject string
+	Author  string
+	When    time.Time
+}
+
+// FileChange is a changed path from `git status --porcelain`.
+type FileChange struct {
+	Status string // Two-letter XY code, e.g. "M ", " M", "??"
+	Path   string
+}
+
+// Remote is a configured remote and its fetch URL.
+type Remote struct {
+	Name string
+	URL  string
+}
+
+// Worktree is a working tree attached to the repository.
+type Worktree struct {
+	Path     string
+	Branch   string // Empty when detached or bare
+	Detached bool
+	Bare     bool
+}
+
+// Overview is everything shown on a repository's dashboard.
+type Overview struct {
+	Status    Status
+	Commits   []Commit // Newest first
+	Changes   []FileChange
+	Stashes   []string // e.g. "stash@{0}: WIP on main: 1234abc message"
+	Remotes   []Remote
+	Worktrees []Worktree
+}
+
+// GetOverview collects the dashboard information for the repository in dir,
+// with up to commits recent commits.
+func GetOverview(ctx context.Context, dir string, commits int) (Overview, error) {
+	var o Overview
+	var err error
+	if o.Status, err = GetStatus(ctx, dir); err != nil {
+		return o, err
+	}
+	if o.Commits, err = Log(ctx, dir, commits); err != nil {
+		return o, err
+	}
+	if o.Changes, err = Changes(ctx, dir); err != nil {
+		return o, err
+	}
+	if o.Stashes, err = Stashes(ctx, dir); err != nil {
+		return o, err
+	}
+	if o.Remotes, err = Remotes(ctx, dir); err != nil {
+		return o, err
+	}
+	if o.Worktrees, err = Worktrees(ctx, dir); err != nil {
+		return o, err
+	}
+	return o, nil
+}
+
+// Log returns the last n commits on HEAD. A repository without commits has
+// an empty log.
+func Log(ctx context.Context, dir string, n int) ([]Commit, error) {
+	if _, err := run(ctx, dir, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return nil, nil
+	}
+	out, err := run(ctx, dir, "log", "-n", strconv.Itoa(n), "--format=%h%x00%s%x00%an%x00%ct")
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, line := range lines(out) {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		c := Commit{Hash: fields[0], Subject: fields[1], Author: fields[2]}
+		if sec, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			c.When = time.Unix(sec, 0)
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// Changes lists the changed and untracked paths in the working tree.
+func Changes(ctx context.Context, dir string) ([]FileChange, error) {
+	out, err := run(ctx, dir, "status", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	var changes []FileChange
+	for _, line := range lines(out) {
+		if len(line) < 4 {
+			continue
+		}
+		changes = append(changes, FileChange{Status: line[:2], Path: line[3:]})
+	}
+	return changes, nil
+}
+
+// Stashes lists the stash entries, newest first.
+func Stashes(ctx context.Context, dir string) ([]string, error) {
+	out, err := run(ctx, dir, "stash", "list")
+	if err != nil {
+		return nil, err
+	}
+	return lines(out), nil
+}
+
+// Remotes lists the remotes with their fetch URLs.
+func Remotes(ctx context.Context, dir string) ([]Remote, error) {
+	out, err := run(ctx, dir, "remote", "-v")
+	if err != nil {
+		return nil, err
+	}
+	var remotes []Remote
+	for _, line := range lines(out) {
+		// <name>\t<url> (fetch)
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[2] == "(fetch)" {
+			remotes = append(remotes, Remote{Name: fields[0], URL: fields[1]})
+		}
+	}
+	return remotes, nil
+}
+
+// Worktrees lists the working trees of the repository, the main one first.
+func Worktrees(ctx context.Context, dir string) ([]Worktree, error) {
+	out, err := run(ctx, dir, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	var worktrees []Worktree
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		if key == "worktree" {
+			worktrees = append(worktrees, Worktree{Path: value})
+			continue
+		}
+		if len(worktrees) == 0 {
+			continue
+		}
+		wt := &worktrees[len(worktrees)-1]
+		switch key {
+		case "branch":
+			wt.Branch = strings.TrimPrefix(value, "refs/heads/")
+		case "detached":
+			wt.Detached = true
+		case "bare":
+			wt.Bare = true
+		}
+	}
+	return worktrees, nil
+}
+
+// Branches lists the local branches.
+func Branches(ctx context.Context, dir string) ([]string, error) {
+	out, err := run(ctx, dir, "for-each-ref", "--sort=-committerdate", "--format=%(refname:short)", "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+	return lines(out), nil
+}
+
+// Switch checks out an existing branch.
+func Switch(ctx context.Context, dir, branch string) error {
+	_, err := run(ctx, dir, "switch", branch)
+	return err
+}
+
+// CreateBranch creates a branch at HEAD and checks it out.
+func CreateBranch(ctx context.Context, dir, branch string) error {
+	if strings.TrimSpace(branch) == "" {
+		return errors.New("branch name must not be empty")
+	}
+	if _, err := run(ctx, dir, "check-ref-format", "--branch", branch); err != nil {
+		return fmt.Errorf("%q is not a valid branch name", branch)
+	}
+	_, err := run(ctx, dir, "switch", "-c", branch)
+	return err
+}
+
+// Stash stashes the changes to tracked files.
+func Stash(ctx context.Context, dir string) error {
+	_, err := run(ctx, dir, "stash", "push")
+	return err
+}
+
+// StashPop applies the latest stash and drops it.
+func StashPop(ctx context.Context, dir string) error {
+	_, err := run(ctx, dir, "stash", "pop")
+	return err
+}
+
+// lines splits command output into non-empty lines.
+func lines(out []byte) []string {
+	var result []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,139 @@
+// Package git reads the state of git repositories by running the git
+// command line.
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status is a summary of a repository's working tree and branch.
+type Status struct {
+	Branch     string // Current branch, empty when HEAD is detached
+	Detached   bool
+	Upstream   string // e.g. "origin/main", empty when the branch has none
+	Ahead      int    // Commits on the branch that aren't on the upstream
+	Behind     int    // Commits on the upstream that aren't on the branch
+	Staged     int
+	Modified   int // Changed in the working tree but not staged
+	Untracked  int
+	Conflicted int
+	Stashes    int
+	LastCommit time.Time // Zero when the repository has no commits
+}
+
+// Clean reports whether the working tree has no changes at all.
+func (s Status) Clean() bool {
+	return s.Staged == 0 && s.Modified == 0 && s.Untracked == 0 && s.Conflicted == 0
+}
+
+// IsRepo reports whether dir is the top of a git working tree.
+func IsRepo(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// GetStatus returns the status of the repository in dir. It runs two git
+// commands: one for the branch and working tree, one for the last commit.
+func GetStatus(ctx context.Context, dir string) (Status, error) {
+	out, err := run(ctx, dir, "status", "--porcelain=v2", "--branch", "--show-stash")
+	if err != nil {
+		return Status{}, err
+	}
+	s, err := parseStatus(out)
+	if err != nil {
+		return Status{}, err
+	}
+
+	// Fails in a repository without commits, which leaves LastCommit zero
+	if out, err := run(ctx, dir, "log", "-1", "--format=%ct"); err == nil {
+		if sec, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			s.LastCommit = time.Unix(sec, 0)
+		}
+	}
+	return s, ctx.Err()
+}
+
+// parseStatus parses the output of
+// `git status --porcelain=v2 --branch --show-stash`.
+func parseStatus(out []byte) (Status, error) {
+	var s Status
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "# branch.head "):
+			s.Branch = strings.TrimPrefix(line, "# branch.head ")
+			if s.Branch == "(detached)" {
+				s.Branch = ""
+				s.Detached = true
+			}
+		case strings.HasPrefix(line, "# branch.upstream "):
+			s.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			// # branch.ab +<ahead> -<behind>
+			if _, err := fmt.Sscanf(line, "# branch.ab +%d -%d", &s.Ahead, &s.Behind); err != nil {
+				return Status{}, fmt.Errorf("parsing %q: %w", line, err)
+			}
+		case strings.HasPrefix(line, "# stash "):
+			n, err := strconv.Atoi(strings.TrimPrefix(line, "# stash "))
+			if err != nil {
+				return Status{}, fmt.Errorf("parsing %q: %w", line, err)
+			}
+			s.Stashes = n
+		case strings.HasPrefix(line, "1 "), strings.HasPrefix(line, "2 "):
+			// 1 <XY> ... for changed entries, 2 <XY> ... for renames and copies;
+			// X is the staged state and Y the working tree state
+			if len(line) < 4 {
+				continue
+			}
+			if line[2] != '.' {
+				s.Staged++
+			}
+			if line[3] != '.' {
+				s.Modified++
+			}
+		case strings.HasPrefix(line, "u "):
+			s.Conflicted++
+		case strings.HasPrefix(line, "? "):
+			s.Untracked++
+		}
+	}
+	return s, scanner.Err()
+}
+
+// run runs git in dir and returns its stdout. A failing command's error
+// includes what git printed to stderr.
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Fail instead of prompting for credentials on the terminal the TUI owns
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); msg != "" && errors.As(err, &exitErr) {
+			return out, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return out, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// IsDirty reports whether the working tree has any changes, including
+// untracked files. It is cheaper than GetStatus.
+func IsDirty(ctx context.Context, dir string) (bool, error) {
+	out, err := run(ctx, dir, "status", "--porcelain")
+	return len(bytes.TrimSpace(out)) > 0, err
+}
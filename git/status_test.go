@@ -0,0 +1,80 @@
+package git
+
+import "testing"
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    Status
+		wantErr bool
+	}{
+		{
+			name: "ahead with changes",
+			out: "# branch.oid 346ab9ae65f9be305e60e9cd02a43d8cf665babe\n" +
+				"# branch.head main\n" +
+				"# branch.upstream origin/main\n" +
+				"# branch.ab +1 -0\n" +
+				"# stash 1\n" +
+				"2 R. N... 100644 100644 100644 78981922613b2afb6025042ff6bd878ac1994e85 78981922613b2afb6025042ff6bd878ac1994e85 R100 a2\ta\n" +
+				"1 .M N... 100644 100644 100644 61780798228d17af2d34fce4cfbdf35556832472 61780798228d17af2d34fce4cfbdf35556832472 b\n" +
+				"1 AM N... 000000 100644 100644 0000000000000000000000000000000000000000 8ba3a16384aacc37d01564b28401755ce8053f51 new\n" +
+				"? untracked\n" +
+				"? notes/todo.txt\n",
+			want: Status{
+				Branch: "main", Upstream: "origin/main", Ahead: 1, Stashes: 1,
+				Staged: 2, Modified: 2, Untracked: 2,
+			},
+		},
+		{
+			name: "behind and ahead",
+			out: "# branch.oid 1f0c2b5d8e7a6f4c3b2a1908f7e6d5c4b3a29180\n" +
+				"# branch.head feature/login\n" +
+				"# branch.upstream origin/feature/login\n" +
+				"# branch.ab +3 -12\n",
+			want: Status{Branch: "feature/login", Upstream: "origin/feature/login", Ahead: 3, Behind: 12},
+		},
+		{
+			name: "merge conflict",
+			out: "# branch.oid 1f0c2b5d8e7a6f4c3b2a1908f7e6d5c4b3a29180\n" +
+				"# branch.head main\n" +
+				"u UU N... 100644 100644 100644 100644 61780798228d17af2d34fce4cfbdf35556832472 8ba3a16384aacc37d01564b28401755ce8053f51 78981922613b2afb6025042ff6bd878ac1994e85 b\n" +
+				"u AA N... 000000 100644 100644 100644 0000000000000000000000000000000000000000 8ba3a16384aacc37d01564b28401755ce8053f51 78981922613b2afb6025042ff6bd878ac1994e85 c\n" +
+				"1 M. N... 100644 100644 100644 61780798228d17af2d34fce4cfbdf35556832472 8ba3a16384aacc37d01564b28401755ce8053f51 d\n",
+			want: Status{Branch: "main", Staged: 1, Conflicted: 2},
+		},
+		{
+			name: "detached",
+			out: "# branch.oid 346ab9ae65f9be305e60e9cd02a43d8cf665babe\n" +
+				"# branch.head (detached)\n" +
+				"1 D. N... 100644 000000 000000 61780798228d17af2d34fce4cfbdf35556832472 0000000000000000000000000000000000000000 gone\n",
+			want: Status{Detached: true, Staged: 1},
+		},
+		{
+			name: "no commits yet",
+			out:  "# branch.oid (initial)\n# branch.head main\n? README.md\n",
+			want: Status{Branch: "main", Untracked: 1},
+		},
+		{
+			name:    "bad ahead behind",
+			out:     "# branch.head main\n# branch.ab ahead\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad stash",
+			out:     "# branch.head main\n# stash many\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatus([]byte(tt.out))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStatus() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseStatus() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"commandy/git"
+)
+
+// gitDashboardMsg delivers a refreshed overview for stateGitDashboard,
+// along with the result of the git action that triggered the refresh.
+type gitDashboardMsg struct {
+	overview git.Overview
+	err      error
+	status   string // Result of the action, if any
+	errAct   error  // Error of the action, if any
+}
+
+type gitBranchesMsg struct {
+	branches []string
+	err      error
+}
+
+// loadGitDashboard runs action (if any) in the repository at dir and then
+// reloads its overview.
+func loadGitDashboard(dir string, action func(ctx context.Context) (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		var msg gitDashboardMsg
+		if action != nil {
+			msg.status, msg.errAct = action(ctx)
+		}
+		msg.overview, msg.err = git.GetOverview(ctx, dir, 8)
+		return msg
+	}
+}
+
+func loadGitBranches(dir string) tea.Cmd {
+	return func() tea.Msg {
+		branches, err := git.Branches(context.Background(), dir)
+		return gitBranchesMsg{branches: branches, err: err}
+	}
+}
+
+// openGitDashboard shows the git dashboard of the selected project.
+func (m model) openGitDashboard() (model, tea.Cmd) {
+	m.state = stateGitDashboard
+	m.cursor = 0
+	m.gitOverview = git.Overview{}
+	m.message = "Loading git status..."
+	m.messageType = "info"
+	return m, loadGitDashboard(m.selectedPath, nil)
+}
+
+func (m model) handleGitDashboardMsg(msg gitDashboardMsg) (model, tea.Cmd) {
+	m.message = ""
+	m.messageType = ""
+	switch {
+	case msg.errAct != nil:
+		m.message = fmt.Sprintf("Error: %v", msg.errAct)
+		m.messageType = "error"
+	case msg.err != nil:
+		m.message = fmt.Sprintf("Error: %v", msg.err)
+		m.messageType = "error"
+	case msg.status != "":
+		m.message = msg.status
+		m.messageType = "success"
+	}
+	if msg.err == nil {
+		m.gitOverview = msg.overview
+	}
+	if m.state == stateInputBranch && msg.errAct != nil {
+		// Let the user fix the name
+		return m, nil
+	}
+	if m.state == stateGitBranches || m.state == stateInputBranch {
+		m.state = stateGitDashboard
+		m.textInput.Reset()
+		m.textInput.Blur()
+	}
+	if items := m.getMenuItems(); m.cursor >= len(items) {
+		m.cursor = len(items) - 1
+	}
+	return m, nil
+}
+
+// gitDashboardItems are the actions offered in stateGitDashboard.
+func (m model) gitDashboardItems() []string {
+	o := m.gitOverview
+	items := []string{"Switch branch", "Create branch"}
+	// Untracked files have no diff to show
+	if o.Status.Staged > 0 || o.Status.Modified > 0 || o.Status.Conflicted > 0 {
+		items = append(items, "View diff")
+	}
+	if o.Status.Staged > 0 || o.Status.Modified > 0 {
+		items = append(items, "Stash changes")
+	}
+	if len(o.Stashes) > 0 {
+		items = append(items, "Pop latest stash")
+	}
+	return append(items, "Refresh", "Back")
+}
+
+func (m model) handleGitDashboard(selected string) (model, tea.Cmd) {
+	dir := m.selectedPath
+	switch selected {
+	case "Switch branch":
+		m.message = ""
+		return m, loadGitBranches(dir)
+	case "Create branch":
+		return m.startInput(stateInputBranch, "New branch name (created from HEAD):", "feature/my-change")
+	case "View diff":
+		m.outputTitle = fmt.Sprintf("Diff · %s", m.selectedProject)
+		return m.startRun(diffTask(dir))
+	case "Stash changes":
+		return m, loadGitDashboard(dir, func(ctx context.Context) (string, error) {
+			return "Stashed the changes", git.Stash(ctx, dir)
+		})
+	case "Pop latest stash":
+		return m, loadGitDashboard(dir, func(ctx context.Context) (string, error) {
+			return "Applied and dropped the latest stash", git.StashPop(ctx, dir)
+		})
+	case "Refresh":
+		return m, loadGitDashboard(dir, nil)
+	case "Back":
+		return m.goBack(), nil
+	}
+	return m, nil
+}
+
+// diffTask shows the staged and unstaged changes of the repository at dir.
+// Before the first commit there is no HEAD to compare with, so the staged
+// changes and those of the working tree are shown one after the other.
+func diffTask(dir string) task {
+	return func(ctx context.Context, w io.Writer) error {
+		diff := []string{"diff", "--color=always", "--stat", "--patch"}
+		if exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--verify", "--quiet", "HEAD").Run() == nil {
+			return commandTask(dir, "git", append(diff, "HEAD")...)(ctx, w)
+		}
+		if err := commandTask(dir, "git", append(diff, "--cached")...)(ctx, w); err != nil {
+			return err
+		}
+		return commandTask(dir, "git", diff...)(ctx, w)
+	}
+}
+
+func (m model) handleGitBranchesMsg(msg gitBranchesMsg) (model, tea.Cmd) {
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Error: %v", msg.err)
+		m.messageType = "error"
+		return m, nil
+	}
+	if len(msg.branches) == 0 {
+		m.message = "No branches yet; commit something first"
+		m.messageType = "info"
+		return m, nil
+	}
+	m.gitBranches = msg.branches
+	m.state = stateGitBranches
+	m.cursor = 0
+	return m, nil
+}
+
+func (m model) handleGitBranches(selected string) (model, tea.Cmd) {
+	if selected == "Back" {
+		return m.goBack(), nil
+	}
+	branch := m.gitBranches[m.cursor]
+	if branch == m.gitOverview.Status.Branch {
+		m.message = fmt.Sprintf("Already on %s", branch)
+		m.messageType = "info"
+		return m, nil
+	}
+	dir := m.selectedPath
+	return m, loadGitDashboard(dir, func(ctx context.Context) (string, error) {
+		return fmt.Sprintf("Switched to %s", branch), git.Switch(ctx, dir, branch)
+	})
+}
+
+// submitBranch creates the branch entered in stateInputBranch.
+func (m model) submitBranch(name string) (model, tea.Cmd) {
+	dir := m.selectedPath
+	name = strings.TrimSpace(name)
+	return m, loadGitDashboard(dir, func(ctx context.Context) (string, error) {
+		return fmt.Sprintf("Created and switched to %s", name), git.CreateBranch(ctx, dir, name)
+	})
+}
+
+// renderGitDashboard shows the repository overview above the actions in
+// stateGitDashboard.
+func (m model) renderGitDashboard() string {
+	o := m.gitOverview
+	st := o.Status
+	var s strings.Builder
+	label := func(l string) string { return subtitleStyle.Render(fmt.Sprintf("  %-10s", l)) }
+
+	branch := st.Branch
+	if st.Detached {
+		branch = "(detached HEAD)"
+	}
+	if branch == "" {
+		return ""
+	}
+	line := label("Branch") + selectedStyle.Render(branch)
+	if st.Upstream != "" {
+		line += dimStyle.Render(" → " + st.Upstream)
+		switch {
+		case st.Ahead == 0 && st.Behind == 0:
+			line += dimStyle.Render(" (up to date)")
+		default:
+			line += subtitleStyle.Render(fmt.Sprintf(" (↑%d ↓%d)", st.Ahead, st.Behind))
+		}
+	}
+	s.WriteString(line + "\n")
+
+	for i, r := range o.Remotes {
+		l := ""
+		if i == 0 {
+			l = "Remote"
+		}
+		s.WriteString(label(l) + normalStyle.Render(r.Name) + " " + dimStyle.Render(r.URL) + "\n")
+	}
+
+	if len(o.Worktrees) > 1 {
+		for i, wt := range o.Worktrees {
+			l := ""
+			if i == 0 {
+				l = "Worktrees"
+			}
+			name := wt.Branch
+			if wt.Detached {
+				name = "detached"
+			}
+			s.WriteString(label(l) + normalStyle.Render(wt.Path) + dimStyle.Render(" ["+name+"]") + "\n")
+		}
+	}
+
+	s.WriteString("\n" + headerStyle.Render("Recent commits") + "\n")
+	if len(o.Commits) == 0 {
+		s.WriteString(dimStyle.Render("  No commits yet") + "\n")
+	}
+	for _, c := range o.Commits {
+		s.WriteString(fmt.Sprintf("  %s %s %s\n",
+			cursorStyle.Render(c.Hash), normalStyle.Render(truncate(c.Subject, 60)),
+			dimStyle.Render(fmt.Sprintf("(%s, %s)", c.Author, timeAgo(c.When)))))
+	}
+
+	s.WriteString("\n" + headerStyle.Render(fmt.Sprintf("Changes (%d)", len(o.Changes))) + "\n")
+	if len(o.Changes) == 0 {
+		s.WriteString(successStyle.Render("  Working tree clean") + "\n")
+	}
+	for i, c := range o.Changes {
+		if i == 10 {
+			s.WriteString(dimStyle.Render(fmt.Sprintf("  … and %d more", len(o.Changes)-i)) + "\n")
+			break
+		}
+		style := subtitleStyle
+		if c.Status == "??" {
+			style = dimStyle
+		} else if c.Status[0] != ' ' {
+			style = successStyle
+		}
+		s.WriteString("  " + style.Render(c.Status) + " " + normalStyle.Render(c.Path) + "\n")
+	}
+
+	if len(o.Stashes) > 0 {
+		s.WriteString("\n" + headerStyle.Render(fmt.Sprintf("Stashes (%d)", len(o.Stashes))) + "\n")
+		for i, stash := range o.Stashes {
+			if i == 3 {
+				s.WriteString(dimStyle.Render(fmt.Sprintf("  … and %d more", len(o.Stashes)-i)) + "\n")
+				break
+			}
+			s.WriteString("  " + dimStyle.Render(stash) + "\n")
+		}
+	}
+	s.WriteString("\n")
+	return s.String()
+}
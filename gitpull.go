@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"commandy/git"
+)
+
+// gitPullAll updates every repository with git.Pull, cfg.Git.Concurrency at
+// a time, and reports per repository what happened. With dryRun the
+// repositories are only fetched.
+func gitPullAll(dryRun bool) task {
+	return func(ctx context.Context, w io.Writer) error {
+		started := time.Now()
+		names, paths := gitRepos()
+		if len(names) == 0 {
+			fmt.Fprintf(w, "No git repositories in %s\n", strings.Join(projectRoots(), ", "))
+			return nil
+		}
+		if dryRun {
+			fmt.Fprintln(w, dimStyle.Render("Dry run: fetching only, working trees are left alone"))
+		}
+
+		opts := git.PullOptions{Mode: cfg.Git.PullMode, DryRun: dryRun}
+		var mu sync.Mutex
+		counts := make(map[git.Outcome]int)
+		steps := make([]step, len(names))
+		for i, name := range names {
+			path := paths[i]
+			steps[i] = step{name, func(ctx context.Context, w io.Writer) error {
+				r := git.Pull(ctx, path, opts)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				mu.Lock()
+				counts[r.Outcome]++
+				mu.Unlock()
+				// One Write per repository so parallel results don't interleave
+				io.WriteString(w, formatPullResult(name, r))
+				return r.Err
+			}}
+		}
+		if err := runStepsParallel(ctx, w, cfg.Git.Concurrency, steps); err != nil {
+			return err
+		}
+
+		var summary []string
+		for _, c := range []struct {
+			outcome git.Outcome
+			label   string
+		}{
+			{git.Updated, "updated"},
+			{git.WouldUpdate, "would update"},
+			{git.UpToDate, "up to date"},
+			{git.Skipped, "skipped"},
+			{git.Failed, "failed"},
+		} {
+			if counts[c.outcome] > 0 {
+				summary = append(summary, fmt.Sprintf("%d %s", counts[c.outcome], c.label))
+			}
+		}
+		summary = append(summary, formatElapsed(time.Since(started)))
+		fmt.Fprintf(w, "\n%d repositories • %s\n", len(names), strings.Join(summary, " • "))
+		if counts[git.Failed] > 0 {
+			return fmt.Errorf("%d of %d repositories failed", counts[git.Failed], len(names))
+		}
+		return nil
+	}
+}
+
+// formatPullResult formats one repository's result of gitPullAll.
+func formatPullResult(name string, r git.PullResult) string {
+	var s strings.Builder
+	commits := func() string {
+		if len(r.Commits) == 1 {
+			return "1 commit"
+		}
+		return fmt.Sprintf("%d commits", len(r.Commits))
+	}
+
+	switch r.Outcome {
+	case git.Updated:
+		s.WriteString(successStyle.Render("✓ "+name) + fmt.Sprintf("  updated %s..%s (%s)", r.From, r.To, commits()))
+	case git.WouldUpdate:
+		s.WriteString(subtitleStyle.Render("→ "+name) + fmt.Sprintf("  would update %s..%s (%s)", r.From, r.To, commits()))
+		for i, c := range r.Commits {
+			if i == 5 {
+				s.WriteString("\n      " + dimStyle.Render(fmt.Sprintf("… and %d more", len(r.Commits)-i)))
+				break
+			}
+			s.WriteString("\n      " + dimStyle.Render(c))
+		}
+	case git.UpToDate:
+		s.WriteString(dimStyle.Render("= "+name) + "  already up to date")
+		if r.Ahead > 0 {
+			s.WriteString(dimStyle.Render(fmt.Sprintf(" (%d unpushed)", r.Ahead)))
+		}
+	case git.Skipped:
+		s.WriteString(subtitleStyle.Render("- "+name) + "  skipped: " + r.Reason)
+	case git.Failed:
+		s.WriteString(errorStyle.Render("✗ "+name) + "  failed")
+		for _, line := range strings.Split(r.Err.Error(), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			s.WriteString("\n      " + errorStyle.Render(line))
+		}
+	}
+	s.WriteString("\n")
+	return s.String()
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"commandy/git"
+)
+
+// gitRepos returns the projects that are git repositories.
+func gitRepos() (names, paths []string) {
+	projects, projectPaths := discoverProjects(nil)
+	for i, path := range projectPaths {
+		if git.IsRepo(path) {
+			names = append(names, projects[i])
+			paths = append(paths, path)
+		}
+	}
+	return names, paths
+}
+
+// gitStatusAll reports the status of every repository, cfg.Git.Concurrency
+// at a time. Each repository's line is written as soon as it is known.
+func gitStatusAll(ctx context.Context, w io.Writer) error {
+	started := time.Now()
+	names, paths := gitRepos()
+	if len(names) == 0 {
+		fmt.Fprintf(w, "No git repositories in %s\n", strings.Join(projectRoots(), ", "))
+		return nil
+	}
+
+	nameWidth := 0
+	for _, name := range names {
+		nameWidth = max(nameWidth, min(len(name), 30))
+	}
+	fmt.Fprintln(w, dimStyle.Render(gitStatusRow(nameWidth, "PROJECT", "BRANCH", "UPSTREAM", "LAST COMMIT", "CHANGES")))
+
+	var (
+		mu                           sync.Mutex
+		clean, changed, behind, errs int
+	)
+	steps := make([]step, len(names))
+	for i, name := range names {
+		path := paths[i]
+		steps[i] = step{name, func(ctx context.Context, w io.Writer) error {
+			s, err := git.GetStatus(ctx, path)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			mu.Lock()
+			switch {
+			case err != nil:
+				errs++
+			case s.Clean():
+				clean++
+			default:
+				changed++
+			}
+			if err == nil && s.Behind > 0 {
+				behind++
+			}
+			mu.Unlock()
+
+			if err != nil {
+				fmt.Fprintln(w, gitStatusRow(nameWidth, name, "", "", "", errorStyle.Render(err.Error())))
+				return err
+			}
+			fmt.Fprintln(w, formatGitStatus(nameWidth, name, s))
+			return nil
+		}}
+	}
+	if err := runStepsParallel(ctx, w, cfg.Git.Concurrency, steps); err != nil {
+		return err
+	}
+
+	summary := fmt.Sprintf("\n%d repositories • %d clean • %d with changes", len(names), clean, changed)
+	if behind > 0 {
+		summary += fmt.Sprintf(" • %d behind upstream", behind)
+	}
+	if errs > 0 {
+		summary += fmt.Sprintf(" • %d failed", errs)
+	}
+	summary += fmt.Sprintf(" • %s", formatElapsed(time.Since(started)))
+	fmt.Fprintln(w, summary)
+	return nil
+}
+
+// formatGitStatus formats one repository's line of gitStatusAll.
+func formatGitStatus(nameWidth int, name string, s git.Status) string {
+	branch := s.Branch
+	if s.Detached {
+		branch = "(detached)"
+	}
+
+	upstream := "-"
+	switch {
+	case s.Upstream == "":
+	case s.Ahead == 0 && s.Behind == 0:
+		upstream = "up to date"
+	default:
+		var parts []string
+		if s.Ahead > 0 {
+			parts = append(parts, fmt.Sprintf("↑%d", s.Ahead))
+		}
+		if s.Behind > 0 {
+			parts = append(parts, fmt.Sprintf("↓%d", s.Behind))
+		}
+		upstream = strings.Join(parts, " ")
+	}
+
+	age := "no commits"
+	if !s.LastCommit.IsZero() {
+		age = timeAgo(s.LastCommit)
+	}
+
+	var changes []string
+	for _, c := range []struct {
+		n    int
+		what string
+	}{
+		{s.Conflicted, "conflicted"},
+		{s.Staged, "staged"},
+		{s.Modified, "modified"},
+		{s.Untracked, "untracked"},
+	} {
+		if c.n > 0 {
+			changes = append(changes, fmt.Sprintf("%d %s", c.n, c.what))
+		}
+	}
+	state := successStyle.Render("clean")
+	if len(changes) > 0 {
+		state = subtitleStyle.Render(strings.Join(changes, ", "))
+	}
+	if s.Stashes == 1 {
+		state += dimStyle.Render(" • 1 stash")
+	} else if s.Stashes > 1 {
+		state += dimStyle.Render(fmt.Sprintf(" • %d stashes", s.Stashes))
+	}
+
+	return gitStatusRow(nameWidth, name, branch, upstream, age, state)
+}
+
+// gitStatusRow lays out the columns of gitStatusAll.
+func gitStatusRow(nameWidth int, name, branch, upstream, age, changes string) string {
+	return fmt.Sprintf("%-*s  %-16s  %-10s  %-11s  %s",
+		nameWidth, truncate(name, nameWidth), truncate(branch, 16), upstream, age, changes)
+}
+
+// truncate shortens s to at most n characters, ending with "…" when cut.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
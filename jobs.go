@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// job is a task started from the menus. Jobs keep running while the user
+// navigates; stateJobs lists them and opens their output.
+type job struct {
+	id     int
+	pager  pager // Output, title and result
+	events <-chan runEvent
+	cancel context.CancelFunc
+	done   chan struct{} // Closed when the task has returned
+	after  func(m model, j *job, err error) model
+}
+
+// runMsg carries a job's output received since its last runMsg and, once
+// the task has finished, its result.
+type runMsg struct {
+	id    int
+	lines []outputLine
+	done  bool
+	err   error
+}
+
+// waitForRun waits for output from a job, collecting everything already
+// queued into one message so fast output doesn't redraw per line.
+func waitForRun(j *job) tea.Cmd {
+	return func() tea.Msg {
+		msg := runMsg{id: j.id}
+		ev := <-j.events
+		for {
+			msg.lines = append(msg.lines, ev.lines...)
+			if ev.done {
+				msg.done, msg.err = true, ev.err
+				return msg
+			}
+			select {
+			case ev = <-j.events:
+			default:
+				return msg
+			}
+		}
+	}
+}
+
+// startRun starts t as a new job. The job's output is shown as it arrives in
+// stateRunningCommand, unless m.background is set, in which case the user
+// stays where they are. m.runAfter, if set, is called when the job finishes.
+func (m model) startRun(t task) (model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan runEvent, 64)
+	done := make(chan struct{})
+	go func() {
+		w := &lineWriter{events: events}
+		err := t(ctx, w)
+		close(done)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		w.flush()
+		events <- runEvent{done: true, err: err}
+	}()
+
+	title := m.outputTitle
+	if title == "" {
+		title = "Output"
+	}
+	returnTo := m.state
+	if m.job != nil && (returnTo == statePager || returnTo == stateRunningCommand) {
+		returnTo = m.job.pager.returnTo
+	}
+
+	m.nextJobID++
+	j := &job{
+		id:     m.nextJobID,
+		pager:  newPager(title, returnTo, m.width, m.height),
+		events: events,
+		cancel: cancel,
+		done:   done,
+		after:  m.runAfter,
+	}
+	m.jobs = append(m.jobs, j)
+	cmd := tea.Batch(waitForRun(j), m.spinner.Tick)
+
+	if m.background {
+		m.message = fmt.Sprintf("Started job #%d: %s • o for jobs", j.id, title)
+		m.messageType = "info"
+		return m, cmd
+	}
+	m.job = j
+	m.state = stateRunningCommand
+	m.message = ""
+	m.messageType = ""
+	return m, cmd
+}
+
+// findJob returns the job with the given id, or nil if it has been removed.
+func (m model) findJob(id int) *job {
+	for _, j := range m.jobs {
+		if j.id == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// runningJobs returns the jobs that haven't finished.
+func (m model) runningJobs() []*job {
+	var running []*job
+	for _, j := range m.jobs {
+		if j.pager.running {
+			running = append(running, j)
+		}
+	}
+	return running
+}
+
+// handleRun adds streamed output to a job and, when it is done, switches to
+// statePager if the job is being watched or posts a notification otherwise.
+func (m model) handleRun(msg runMsg) (model, tea.Cmd) {
+	j := m.findJob(msg.id)
+	if j == nil {
+		return m, nil
+	}
+	j.pager.appendOutput(msg.lines)
+	if !msg.done {
+		return m, waitForRun(j)
+	}
+
+	j.cancel()
+	j.pager.finish(msg.err)
+	if j.after != nil {
+		m = j.after(m, j, msg.err)
+	}
+	if m.state == stateRunningCommand && m.job == j {
+		m.state = statePager
+		return m, nil
+	}
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Job #%d %s failed (%s) • o for jobs", j.id, j.pager.title, j.pager.result())
+		m.messageType = "error"
+	} else {
+		m.message = fmt.Sprintf("Job #%d %s finished • o for jobs", j.id, j.pager.title)
+		m.messageType = "success"
+	}
+	return m, nil
+}
+
+// handleSpinner advances the spinner while any job is running.
+func (m model) handleSpinner(msg spinner.TickMsg) (model, tea.Cmd) {
+	if len(m.runningJobs()) == 0 {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+// updateRunning handles keys in stateRunningCommand. ctrl+c and x cancel the
+// job; esc hides the running view while the job keeps going in the
+// background.
+func (m model) updateRunning(msg tea.KeyMsg) (model, tea.Cmd) {
+	if m.job.pager.prompt == "" {
+		switch msg.String() {
+		case "ctrl+c", "x":
+			m.job.stop()
+			return m, nil
+		case "q", "esc":
+			m.state = m.job.pager.returnTo
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.job.pager, cmd = m.job.pager.Update(msg)
+	return m, cmd
+}
+
+// stop cancels the job if it is still running.
+func (j *job) stop() {
+	if j.pager.running && !j.pager.cancelling {
+		j.pager.cancelling = true
+		j.cancel()
+	}
+}
+
+// openJobs shows the jobs panel.
+func (m model) openJobs() model {
+	if m.state != stateJobs {
+		m.prevState = m.state
+	}
+	m.state = stateJobs
+	m.cursor = 0
+	m.message = ""
+	m.messageType = ""
+	return m
+}
+
+// jobsNewestFirst is the order jobs are listed in stateJobs.
+func (m model) jobsNewestFirst() []*job {
+	jobs := slices.Clone(m.jobs)
+	slices.Reverse(jobs)
+	return jobs
+}
+
+// jobLabel is a job's line in the jobs panel.
+func (m model) jobLabel(j *job) string {
+	title := j.pager.title
+	if len(title) > 32 {
+		title = title[:29] + "..."
+	}
+	var status string
+	switch {
+	case j.pager.cancelling:
+		status = m.spinner.View() + "cancelling..."
+	case j.pager.running:
+		status = m.spinner.View() + "running " + formatElapsed(time.Since(j.pager.started))
+	case j.pager.err != nil:
+		status = "✗ " + j.pager.result() + " • " + formatElapsed(j.pager.elapsed)
+	default:
+		status = "✓ " + j.pager.result() + " • " + formatElapsed(j.pager.elapsed)
+	}
+	return fmt.Sprintf("#%-3d %-32s %s", j.id, title, status)
+}
+
+// handleJobs opens the output of the job selected in stateJobs.
+func (m model) handleJobs(selected string) (model, tea.Cmd) {
+	jobs := m.jobsNewestFirst()
+	if selected == "Back" || m.cursor >= len(jobs) {
+		m.state = m.prevState
+		m.cursor = 0
+		return m, nil
+	}
+	j := jobs[m.cursor]
+	j.pager.returnTo = stateJobs
+	m.job = j
+	m.state = statePager
+	if j.pager.running {
+		m.state = stateRunningCommand
+	}
+	return m, nil
+}
+
+// cancelSelectedJob cancels the job under the cursor in stateJobs.
+func (m model) cancelSelectedJob() model {
+	if jobs := m.jobsNewestFirst(); m.cursor < len(jobs) {
+		jobs[m.cursor].stop()
+	}
+	return m
+}
+
+// removeSelectedJob removes the finished job under the cursor in stateJobs.
+func (m model) removeSelectedJob() model {
+	jobs := m.jobsNewestFirst()
+	if m.cursor >= len(jobs) {
+		return m
+	}
+	j := jobs[m.cursor]
+	if j.pager.running {
+		m.message = fmt.Sprintf("Job #%d is still running; press x to cancel it first", j.id)
+		m.messageType = "error"
+		return m
+	}
+	m.jobs = slices.DeleteFunc(slices.Clone(m.jobs), func(other *job) bool { return other == j })
+	if m.job == j {
+		m.job = nil
+	}
+	if m.cursor > len(m.jobs) {
+		m.cursor = len(m.jobs)
+	}
+	return m
+}
+
+// renderRunIndicator is the line shown under the menus while jobs are
+// running in the background.
+func (m model) renderRunIndicator() string {
+	running := m.runningJobs()
+	switch {
+	case len(running) == 0 || m.state == stateJobs:
+		return ""
+	case len(running) == 1:
+		j := running[0]
+		return m.spinner.View() + normalStyle.Render(j.pager.title) + " " +
+			dimStyle.Render(fmt.Sprintf("running %s • o for jobs", formatElapsed(time.Since(j.pager.started))))
+	default:
+		return m.spinner.View() + normalStyle.Render(fmt.Sprintf("%d jobs running", len(running))) + " " +
+			dimStyle.Render("• o for jobs")
+	}
+}
+
+// stopJobs cancels the running jobs and waits up to killTimeout for them to
+// stop, so quitting doesn't leave their processes behind.
+func (m model) stopJobs() {
+	running := m.runningJobs()
+	for _, j := range running {
+		j.cancel()
+	}
+	deadline := time.After(killTimeout)
+	for _, j := range running {
+		select {
+		case <-j.done:
+		case <-deadline:
+			return
+		}
+	}
+}
+
+func newSpinner() spinner.Model {
+	return spinner.New(
+		spinner.WithSpinner(spinner.Dot),
+		spinner.WithStyle(cursorStyle),
+	)
+}
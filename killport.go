@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"commandy/ports"
+)
+
+// killTimeout is how long killPortProcesses waits after SIGTERM before
+// sending SIGKILL.
+const killTimeout = 5 * time.Second
+
+// processInfo describes a process listening on a port.
+type processInfo struct {
+	PID     int
+	Name    string
+	User    string
+	Command string // Full command line
+}
+
+type portProcessesMsg struct {
+	port  int
+	procs []processInfo
+	err   error
+}
+
+// findPortProcesses looks up the processes listening on the given TCP port.
+func findPortProcesses(port int) tea.Cmd {
+	return func() tea.Msg {
+		listeners, err := ports.Scan()
+		if err != nil {
+			return portProcessesMsg{port: port, err: err}
+		}
+
+		var procs []processInfo
+		seen := make(map[int]bool)
+		hidden := false
+		for _, l := range ports.Filter(listeners, ports.Range{Lo: port, Hi: port}) {
+			if l.PID == 0 {
+				hidden = true
+				continue
+			}
+			if seen[l.PID] {
+				continue
+			}
+			seen[l.PID] = true
+			procs = append(procs, lookupProcess(l.PID))
+		}
+		if len(procs) == 0 && hidden {
+			return portProcessesMsg{port: port, err: errors.New("the listening process belongs to another user")}
+		}
+		return portProcessesMsg{port: port, procs: procs}
+	}
+}
+
+// lookupProcess fills in the name, user and command line of a process from
+// /proc on Linux and ps elsewhere. Fields that can't be read are left empty.
+func lookupProcess(pid int) processInfo {
+	p := processInfo{PID: pid}
+
+	if runtime.GOOS == "linux" {
+		dir := filepath.Join("/proc", strconv.Itoa(pid))
+		if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+			p.Command = strings.TrimSpace(string(bytes.ReplaceAll(cmdline, []byte{0}, []byte{' '})))
+		}
+		if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+			p.Name = strings.TrimSpace(string(comm))
+		}
+		if f, err := os.Open(filepath.Join(dir, "status")); err == nil {
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				// Uid:	<real>	<effective>	<saved>	<fs>
+				if fields := strings.Fields(scanner.Text()); len(fields) > 1 && fields[0] == "Uid:" {
+					p.User = fields[1]
+					if u, err := user.LookupId(fields[1]); err == nil {
+						p.User = u.Username
+					}
+					break
+				}
+			}
+			f.Close()
+		}
+		return p
+	}
+
+	// One ps call per field, as both comm and command may contain spaces
+	ps := func(field string) string {
+		output, _ := exec.Command("ps", "-o", field+"=", "-p", strconv.Itoa(pid)).Output()
+		return strings.TrimSpace(string(output))
+	}
+	p.User = ps("user")
+	if comm := ps("comm"); comm != "" {
+		p.Name = filepath.Base(comm)
+	}
+	p.Command = ps("command")
+	return p
+}
+
+// killResult is what became of one process killed by killPortProcesses.
+type killResult struct {
+	text    string // e.g. "terminated"
+	stopped bool
+}
+
+// killPortProcesses sends SIGTERM to each process, waits up to killTimeout
+// for them to exit and sends SIGKILL to those still running.
+func killPortProcesses(port int, procs []processInfo) tea.Cmd {
+	return func() tea.Msg {
+		results := make(map[int]killResult)
+		running := make(map[int]*os.Process)
+
+		for _, p := range procs {
+			proc, err := os.FindProcess(p.PID)
+			if err == nil {
+				err = proc.Signal(syscall.SIGTERM)
+			}
+			if err != nil {
+				results[p.PID] = killResult{text: fmt.Sprintf("failed to send SIGTERM: %v", err)}
+				continue
+			}
+			running[p.PID] = proc
+		}
+
+		deadline := time.Now().Add(killTimeout)
+		for len(running) > 0 && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+			for pid, proc := range running {
+				if proc.Signal(syscall.Signal(0)) != nil {
+					results[pid] = killResult{text: "terminated", stopped: true}
+					delete(running, pid)
+				}
+			}
+		}
+
+		for pid, proc := range running {
+			err := proc.Signal(syscall.SIGKILL)
+			switch {
+			case errors.Is(err, os.ErrProcessDone):
+				results[pid] = killResult{text: "terminated", stopped: true}
+			case err != nil:
+				results[pid] = killResult{text: fmt.Sprintf("still running, SIGKILL failed: %v", err)}
+			default:
+				results[pid] = killResult{text: fmt.Sprintf("killed with SIGKILL after %s", killTimeout), stopped: true}
+			}
+		}
+
+		var lines []string
+		failed := false
+		for _, p := range procs {
+			result := results[p.PID]
+			if !result.stopped {
+				failed = true
+			}
+			lines = append(lines, fmt.Sprintf("Port %d: PID %d (%s) %s", port, p.PID, p.Name, result.text))
+		}
+
+		output := strings.Join(lines, "\n")
+		if failed {
+			return statusMsg{text: output, err: fmt.Errorf("could not stop every process on port %d", port)}
+		}
+		return statusMsg{text: output}
+	}
+}
+
+// renderPortProcesses lists the processes shown in stateConfirmKillPort.
+func (m model) renderPortProcesses() string {
+	var s strings.Builder
+	for _, p := range m.portProcs {
+		s.WriteString(fmt.Sprintf("  %s %s %s\n",
+			headerStyle.Render(fmt.Sprintf("PID %d", p.PID)),
+			subtitleStyle.Render(p.User),
+			normalStyle.Render(p.Name)))
+		if p.Command != "" {
+			s.WriteString("    " + dimStyle.Render(p.Command) + "\n")
+		}
+	}
+	s.WriteString("\n")
+	return s.String()
+}
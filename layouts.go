@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"commandy/tmux"
+)
+
+// projectFile is the commandy settings file at the top of a project.
+const projectFile = ".commandy.yaml"
+
+// projectSettings is the contents of a project's .commandy.yaml:
+//
+//	windows:
+//	  - name: editor
+//	    panes: [nvim .]
+//	  - name: dev
+//	    layout: main-vertical
+//	    panes: [npm run dev, npm run logs]
+//	  - name: claude
+//	    panes: [claude]
+//
+// or, to use a layout from the config, "layout: <name>".
+type projectSettings struct {
+	Layout  string         `yaml:"layout"`
+	Windows []WindowConfig `yaml:"windows"`
+}
+
+// projectLayout returns the windows a new session of project, at dir,
+// starts with: those of its .commandy.yaml, or of the first layout in the
+// config, by name, whose projects match it. It returns nil when the project
+// has no layout.
+func projectLayout(project, dir string) ([]WindowConfig, error) {
+	data, err := os.ReadFile(filepath.Join(dir, projectFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		var settings projectSettings
+		if err := yaml.Unmarshal(data, &settings); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(dir, projectFile), err)
+		}
+		switch {
+		case len(settings.Windows) > 0:
+			return settings.Windows, nil
+		case settings.Layout != "":
+			l, ok := cfg.Tmux.Layouts[settings.Layout]
+			if !ok {
+				return nil, fmt.Errorf("%s: unknown layout %q", filepath.Join(dir, projectFile), settings.Layout)
+			}
+			return l.Windows, nil
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Tmux.Layouts)) {
+		l := cfg.Tmux.Layouts[name]
+		for _, pattern := range l.Projects {
+			if ok, _ := path.Match(pattern, project); ok {
+				return l.Windows, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// startLayout creates the detached session name for the project at dir
+// with the windows of layout, the first one selected. Each pane starts a
+// shell and has its command typed in, so it is still there to rerun when
+// the command exits.
+func startLayout(ctx context.Context, client tmux.Client, name, dir string, layout []WindowConfig) error {
+	var first string
+	for i, w := range layout {
+		wdir := dir
+		if w.Dir != "" {
+			wdir = expandPath(w.Dir)
+			if !filepath.IsAbs(wdir) {
+				wdir = filepath.Join(dir, wdir)
+			}
+		}
+
+		var pane string
+		var err error
+		if i == 0 {
+			pane, err = client.NewSession(ctx, name, wdir)
+			first = pane
+		} else {
+			pane, err = client.NewWindow(ctx, name, wdir)
+		}
+		if err != nil {
+			return err
+		}
+		if w.Name != "" {
+			if err := client.RenameWindow(ctx, pane, w.Name); err != nil {
+				return err
+			}
+		}
+
+		arrangement := w.Layout
+		if arrangement == "" {
+			arrangement = "tiled"
+		}
+		panes := []string{pane}
+		for range max(len(w.Panes)-1, 0) {
+			p, err := client.SplitWindow(ctx, panes[len(panes)-1], wdir)
+			if err != nil {
+				return err
+			}
+			panes = append(panes, p)
+			// Rearrange as the panes are added, so that there is room to split
+			if err := client.SelectLayout(ctx, pane, arrangement); err != nil {
+				return err
+			}
+		}
+		for j, command := range w.Panes {
+			if command == "" {
+				continue
+			}
+			if err := client.SendKeys(ctx, panes[j], command); err != nil {
+				return err
+			}
+		}
+	}
+	if first == "" {
+		return nil
+	}
+	return client.Select(ctx, first)
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+//go:embed menus.toml
+var defaultMenus string
+
+// Menu is a list of items shown by stateMenu. Menus are keyed by id in
+// Config.Menus; "main" is the top-level menu.
+type Menu struct {
+	Title  string     `toml:"title"`
+	Items  []MenuItem `toml:"items"`
+	Append []MenuItem `toml:"append"` // Added to the built-in items of the same menu, before a trailing "back"
+}
+
+// MenuItem is a single menu entry. Exactly one of Action, Menu and Command
+// must be set.
+type MenuItem struct {
+	Label   string   `toml:"label"`
+	Action  string   `toml:"action"`  // Built-in action, see builtinActions
+	Menu    string   `toml:"menu"`    // Id of a submenu to open
+	Command []string `toml:"command"` // argv of a command to run
+	Dir     string   `toml:"dir"`     // Working directory; "{project}" asks for a project first
+	Mode    string   `toml:"mode"`    // How to run Command, see commandModes
+	Require []string `toml:"require"` // Files a project must contain to be offered for {project}
+	Host    string   `toml:"host"`    // Host for the "connect" action
+	Message string   `toml:"message"` // Shown once a "start" command has been launched
+	Hidden  bool     `toml:"hidden"`
+}
+
+// commandModes are the ways a menu command can be run.
+var commandModes = map[string]string{
+	"capture": "stream the output into the pager while it runs (default)",
+	"tty":     "hand the terminal to the command and quit commandy when it exits",
+	"return":  "hand the terminal to the command and come back to the menu when it exits",
+	"start":   "start the command without waiting for it",
+}
+
+// builtinActions are the actions menu items can refer to with
+// `action = "..."`. label is used when the item doesn't set one; project
+// marks actions that operate on a project chosen from stateSelectProject.
+var builtinActions = map[string]struct {
+	label   string
+	project bool
+}{
+	"back":                   {label: "Back"},
+	"exit":                   {label: "Exit"},
+	"connect":                {label: "Connect to %s"},
+	"quick-ssh":              {label: "SSH to %s"},
+	"browse-projects":        {label: "Browse Projects"},
+	"setup-project":          {label: "Setup New Project"},
+	"sessions":               {label: "Sessions"},
+	"jobs":                   {label: "Jobs"},
+	"kill-port":              {label: "Kill process on port"},
+	"check-ports":            {label: "Check port usage"},
+	"git-status-all":         {label: "Git status (all projects)"},
+	"git-pull-all":           {label: "Git pull (all projects)"},
+	"git-pull-dry-run":       {label: "Git pull dry run (all projects)"},
+	"port-authority-check":   {label: "Check project ports", project: true},
+	"port-authority-setup":   {label: "Setup ports for project", project: true},
+	"port-authority-update":  {label: "Update project port", project: true},
+	"port-authority-release": {label: "Release project port", project: true},
+	"port-authority-list":    {label: "View all registered ports"},
+	"docker-cleanup":         {label: "Docker cleanup"},
+	"brew-update":            {label: "Homebrew update"},
+	"remove-node-modules":    {label: "Remove node_modules (select project)", project: true},
+	"clear-all-caches":       {label: "Clear all caches"},
+	"npm-outdated-all":       {label: "Check outdated (all)"},
+}
+
+// loadDefaultMenus parses the embedded menus.toml.
+func loadDefaultMenus() map[string]*Menu {
+	var c struct {
+		Menus map[string]*Menu `toml:"menus"`
+	}
+	if _, err := toml.Decode(defaultMenus, &c); err != nil {
+		panic(fmt.Sprintf("menus.toml: %v", err))
+	}
+	return c.Menus
+}
+
+// mergeMenus applies the menus from a config file on top of the built-in
+// ones: a menu with items replaces the built-in menu of the same id, a menu
+// with only append extends it. Appended items go before a trailing "back",
+// also in menus that set items themselves or have no built-in.
+func mergeMenus(base, user map[string]*Menu) map[string]*Menu {
+	merged := make(map[string]*Menu, len(base))
+	for id, menu := range base {
+		merged[id] = menu
+	}
+	for id, menu := range user {
+		m := &Menu{Title: menu.Title, Items: slices.Clone(menu.Items)}
+		if b, ok := base[id]; ok && menu.Items == nil {
+			m.Items = slices.Clone(b.Items)
+			if m.Title == "" {
+				m.Title = b.Title
+			}
+		}
+		at := len(m.Items)
+		if at > 0 && m.Items[at-1].Action == "back" {
+			at--
+		}
+		m.Items = slices.Insert(m.Items, at, menu.Append...)
+		merged[id] = m
+	}
+	return merged
+}
+
+func validateMenus(menus map[string]*Menu) []string {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if menus["main"] == nil {
+		addf("menus: a \"main\" menu is required")
+	}
+
+	ids := make([]string, 0, len(menus))
+	for id := range menus {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		menu := menus[id]
+		if len(menu.Items) == 0 && len(menu.Append) == 0 {
+			addf("menus.%s: has no items", id)
+		}
+		for i, item := range append(slices.Clone(menu.Items), menu.Append...) {
+			where := fmt.Sprintf("menus.%s.items[%d]", id, i)
+			if item.Label != "" {
+				where = fmt.Sprintf("menus.%s %q", id, item.Label)
+			}
+
+			kinds := 0
+			for _, set := range []bool{item.Action != "", item.Menu != "", len(item.Command) > 0} {
+				if set {
+					kinds++
+				}
+			}
+			if kinds != 1 {
+				addf("%s: exactly one of action, menu or command must be set", where)
+				continue
+			}
+
+			switch {
+			case item.Action != "":
+				if _, ok := builtinActions[item.Action]; !ok {
+					addf("%s: unknown action %q", where, item.Action)
+				}
+			case item.Menu != "":
+				if menus[item.Menu] == nil {
+					addf("%s: unknown menu %q", where, item.Menu)
+				}
+			default:
+				if item.Label == "" {
+					addf("%s: label is required for commands", where)
+				}
+				if _, ok := commandModes[item.Mode]; item.Mode != "" && !ok {
+					addf("%s: unknown mode %q (want capture, tty, return or start)", where, item.Mode)
+				}
+				if item.Command[0] == "" {
+					addf("%s: command must not start with an empty argument", where)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// currentMenu returns the menu on top of the menu stack.
+func (m model) currentMenu() *Menu {
+	if len(m.menuStack) == 0 {
+		return cfg.Menus["main"]
+	}
+	return cfg.Menus[m.menuStack[len(m.menuStack)-1]]
+}
+
+// openMenu pushes the given menu onto the menu stack and shows it.
+func (m model) openMenu(id string) model {
+	m.menuStack = append(slices.Clone(m.menuStack), id)
+	m.state = stateMenu
+	m.cursor = 0
+	return m
+}
+
+// menuEntries returns the visible items of the current menu, with
+// host-dependent items expanded.
+func (m model) menuEntries() []MenuItem {
+	menu := m.currentMenu()
+	if menu == nil {
+		return nil
+	}
+
+	var entries []MenuItem
+	if menu == cfg.Menus["main"] {
+		// Pinned and recent projects come first, opened with "open-project"
+		for _, p := range m.recent {
+			label := "◷ " + p.name
+			if p.pinned {
+				label = "★ " + p.name
+			}
+			entries = append(entries, MenuItem{Label: label, Action: "open-project", Dir: p.path})
+		}
+	}
+	for _, item := range menu.Items {
+		if item.Hidden || item.missingSetting() {
+			continue
+		}
+		switch item.Action {
+		case "connect":
+			hosts := cfg.connectHosts()
+			if item.Host != "" {
+				hosts = nil
+				if h := cfg.host(item.Host); h != nil && h != currentHost {
+					hosts = append(hosts, h)
+				}
+			}
+			for _, h := range hosts {
+				entry := item
+				entry.Host = h.Name
+				entry.Label = menuLabel(item, h.Name)
+				entries = append(entries, entry)
+			}
+			continue
+		case "quick-ssh":
+			h := cfg.host(cfg.QuickSSH)
+			if h == nil {
+				continue
+			}
+			entry := item
+			entry.Action = "connect"
+			entry.Host = h.Name
+			entry.Label = menuLabel(item, h.Name)
+			entries = append(entries, entry)
+			continue
+		case "sessions":
+			if !cfg.Sessions || m.mux == nil {
+				continue
+			}
+		case "jobs":
+			if len(m.jobs) == 0 {
+				continue
+			}
+			if running := len(m.runningJobs()); running > 0 {
+				item.Label = fmt.Sprintf("%s (%d running)", menuLabel(item), running)
+				entries = append(entries, item)
+				continue
+			}
+		}
+		item.Label = menuLabel(item)
+		entries = append(entries, item)
+	}
+	return entries
+}
+
+// menuLabel returns the item's label, falling back to the label of its
+// built-in action or the title of its submenu.
+func menuLabel(item MenuItem, args ...any) string {
+	label := item.Label
+	if label == "" {
+		if a, ok := builtinActions[item.Action]; ok {
+			label = a.label
+		} else if sub := cfg.Menus[item.Menu]; sub != nil {
+			label = sub.Title
+		}
+	}
+	if len(args) > 0 && strings.Contains(label, "%s") {
+		label = fmt.Sprintf(label, args...)
+	}
+	return label
+}
+
+// needsProject reports whether the item has to be run against a project
+// picked from stateSelectProject, because it uses {project} or
+// {project_name}.
+func (item MenuItem) needsProject() bool {
+	if item.Action != "" {
+		return builtinActions[item.Action].project
+	}
+	usesProject := func(s string) bool {
+		return strings.Contains(s, "{project}") || strings.Contains(s, "{project_name}")
+	}
+	return usesProject(item.Dir) || slices.ContainsFunc(item.Command, usesProject)
+}
+
+func (m model) handleMenu(item MenuItem) (model, tea.Cmd) {
+	m.outputTitle = item.Label
+	if item.Menu != "" {
+		return m.openMenu(item.Menu), nil
+	}
+
+	if item.needsProject() {
+		m.pendingItem = item
+		m.state = stateSelectProject
+		m.cursor = 0
+		m.loadProjects(item.Require)
+		return m, m.loadProjectInfo()
+	}
+
+	if item.Action != "" {
+		return m.runAction(item, "", "")
+	}
+	return m.runMenuCommand(item, "", "")
+}
+
+// runMenuCommand runs a command item, with project and projectPath filled
+// in when the item needed a project.
+func (m model) runMenuCommand(item MenuItem, project, projectPath string) (model, tea.Cmd) {
+	r := placeholders(project, projectPath)
+	args := make([]string, len(item.Command))
+	for i, arg := range item.Command {
+		args[i] = r.Replace(arg)
+	}
+	dir := expandPath(r.Replace(item.Dir))
+
+	switch item.Mode {
+	case "tty":
+		return m, execInDirAndQuit(dir, args[0], args[1:]...)
+	case "return":
+		return m, execInDirAndReturn(dir, args[0], args[1:]...)
+	case "start":
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = dir
+		if err := cmd.Start(); err != nil {
+			m.message = fmt.Sprintf("Error: %v", err)
+			m.messageType = "error"
+			return m, nil
+		}
+		m.message = item.Message
+		m.messageType = "success"
+		return m, nil
+	default:
+		return m.startRun(commandTask(dir, args[0], args[1:]...))
+	}
+}
+
+// placeholders returns the replacer for the {...} placeholders allowed in
+// menu commands and working directories.
+func placeholders(project, projectPath string) *strings.Replacer {
+	pairs := []string{
+		"{project}", projectPath,
+		"{project_name}", project,
+		"{projects_dir}", projectsDir,
+		"{host}", hostname,
+	}
+	for placeholder, value := range settingPlaceholders() {
+		pairs = append(pairs, placeholder, value)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// settingPlaceholders are the placeholders standing for config settings.
+func settingPlaceholders() map[string]string {
+	return map[string]string{
+		"{database_url}":             cfg.DatabaseURL,
+		"{port_authority_api}":       cfg.PortAuthority.API,
+		"{port_authority_dashboard}": cfg.PortAuthority.Dashboard,
+	}
+}
+
+// missingSetting reports whether the item's command or working directory
+// uses a placeholder whose setting is empty, such as a PostgreSQL shell
+// without a database_url. Such items are hidden.
+func (item MenuItem) missingSetting() bool {
+	for placeholder, value := range settingPlaceholders() {
+		if value != "" {
+			continue
+		}
+		if strings.Contains(item.Dir, placeholder) {
+			return true
+		}
+		for _, arg := range item.Command {
+			if strings.Contains(arg, placeholder) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// labels returns the labels of items, or their action or menu when unlabelled.
+func labels(items []MenuItem) []string {
+	var out []string
+	for _, item := range items {
+		switch {
+		case item.Label != "":
+			out = append(out, item.Label)
+		case item.Action != "":
+			out = append(out, item.Action)
+		default:
+			out = append(out, item.Menu)
+		}
+	}
+	return out
+}
+
+func TestMergeMenus(t *testing.T) {
+	base := map[string]*Menu{
+		"main":  {Title: "Main", Items: []MenuItem{{Label: "Tools", Menu: "tools"}, {Action: "exit"}}},
+		"tools": {Title: "Tools", Items: []MenuItem{{Action: "kill-port"}, {Action: "back"}}},
+	}
+	seed := MenuItem{Label: "Seed", Command: []string{"npm", "run", "seed"}}
+	lint := MenuItem{Label: "Lint", Command: []string{"npm", "run", "lint"}}
+
+	tests := []struct {
+		name      string
+		user      *Menu
+		id        string
+		wantTitle string
+		want      []string
+	}{
+		{
+			name:      "append extends the built-in menu before back",
+			id:        "tools",
+			user:      &Menu{Append: []MenuItem{seed}},
+			wantTitle: "Tools",
+			want:      []string{"kill-port", "Seed", "back"},
+		},
+		{
+			name:      "append without back goes last",
+			id:        "main",
+			user:      &Menu{Title: "Home", Append: []MenuItem{seed}},
+			wantTitle: "Home",
+			want:      []string{"Tools", "exit", "Seed"},
+		},
+		{
+			name:      "items replace the built-in menu",
+			id:        "tools",
+			user:      &Menu{Items: []MenuItem{lint, {Action: "back"}}},
+			wantTitle: "",
+			want:      []string{"Lint", "back"},
+		},
+		{
+			name:      "items and append are both kept",
+			id:        "tools",
+			user:      &Menu{Items: []MenuItem{lint, {Action: "back"}}, Append: []MenuItem{seed}},
+			want:      []string{"Lint", "Seed", "back"},
+			wantTitle: "",
+		},
+		{
+			name:      "a new menu with only append gets its items",
+			id:        "scripts",
+			user:      &Menu{Title: "Scripts", Append: []MenuItem{seed, lint}},
+			wantTitle: "Scripts",
+			want:      []string{"Seed", "Lint"},
+		},
+		{
+			name:      "a new menu with items and append",
+			id:        "scripts",
+			user:      &Menu{Title: "Scripts", Items: []MenuItem{lint, {Action: "back"}}, Append: []MenuItem{seed}},
+			wantTitle: "Scripts",
+			want:      []string{"Lint", "Seed", "back"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergeMenus(base, map[string]*Menu{tt.id: tt.user})
+			m := merged[tt.id]
+			if m == nil {
+				t.Fatalf("menu %s missing after merge", tt.id)
+			}
+			if got := labels(m.Items); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("items = %v, want %v", got, tt.want)
+			}
+			if m.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", m.Title, tt.wantTitle)
+			}
+			if problems := validateMenus(merged); len(problems) > 0 {
+				t.Errorf("validateMenus() = %v", problems)
+			}
+		})
+	}
+
+	// The built-in menus are left alone
+	if got := labels(base["tools"].Items); strings.Join(got, ",") != "kill-port,back" {
+		t.Errorf("base tools menu changed to %v", got)
+	}
+}
+
+func TestValidateMenus(t *testing.T) {
+	tests := []struct {
+		name  string
+		menus map[string]*Menu
+		want  []string
+	}{
+		{
+			name: "valid",
+			menus: map[string]*Menu{
+				"main": {Items: []MenuItem{{Label: "Tools", Menu: "tools"}, {Action: "exit"}}},
+				"tools": {Items: []MenuItem{
+					{Label: "Lint", Command: []string{"npm", "run", "lint"}, Mode: "return"},
+					{Action: "back"},
+				}},
+			},
+		},
+		{
+			name:  "no main menu",
+			menus: map[string]*Menu{"tools": {Items: []MenuItem{{Action: "back"}}}},
+			want:  []string{`menus: a "main" menu is required`},
+		},
+		{
+			name:  "empty menu",
+			menus: map[string]*Menu{"main": {Title: "Main"}},
+			want:  []string{"menus.main: has no items"},
+		},
+		{
+			name: "bad items",
+			menus: map[string]*Menu{"main": {Items: []MenuItem{
+				{Label: "Both", Action: "exit", Menu: "tools"},
+				{Label: "Neither"},
+				{Action: "launch-rockets"},
+				{Label: "Nowhere", Menu: "missing"},
+				{Command: []string{"ls"}},
+				{Label: "Odd", Command: []string{"ls"}, Mode: "background"},
+				{Label: "Empty", Command: []string{""}},
+			}}},
+			want: []string{
+				`menus.main "Both": exactly one of action, menu or command must be set`,
+				`menus.main "Neither": exactly one of action, menu or command must be set`,
+				`menus.main.items[2]: unknown action "launch-rockets"`,
+				`menus.main "Nowhere": unknown menu "missing"`,
+				`menus.main.items[4]: label is required for commands`,
+				`menus.main "Odd": unknown mode "background" (want capture, tty, return or start)`,
+				`menus.main "Empty": command must not start with an empty argument`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateMenus(tt.menus)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("validateMenus() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDefaultMenusValid(t *testing.T) {
+	if problems := validateMenus(loadDefaultMenus()); len(problems) > 0 {
+		t.Errorf("menus.toml: %v", problems)
+	}
+}
+
+func TestNeedsProject(t *testing.T) {
+	tests := []struct {
+		name string
+		item MenuItem
+		want bool
+	}{
+		{"project dir", MenuItem{Command: []string{"npm", "test"}, Dir: "{project}"}, true},
+		{"project arg", MenuItem{Command: []string{"code", "{project}"}}, true},
+		{"project name", MenuItem{Command: []string{"echo", "{project_name}"}}, true},
+		{"projects_dir dir", MenuItem{Command: []string{"ls"}, Dir: "{projects_dir}"}, false},
+		{"projects_dir arg", MenuItem{Command: []string{"du", "-sh", "{projects_dir}"}}, false},
+		{"no placeholder", MenuItem{Command: []string{"brew", "update"}}, false},
+		{"project action", MenuItem{Action: "port-authority-check"}, true},
+		{"plain action", MenuItem{Action: "kill-port"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.item.needsProject(); got != tt.want {
+			t.Errorf("%s: needsProject() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
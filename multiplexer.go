@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"commandy/screen"
+	"commandy/tmux"
+	"commandy/zellij"
+)
+
+// multiplexer is the terminal multiplexer project sessions live in, see
+// sessionMux. Windows, panes, layouts and attached clients are only shown
+// for tmux, which has them; the session browser leaves them out for the
+// others.
+type multiplexer interface {
+	Name() string
+	Available() bool
+	// Inside reports whether commandy runs in one of its sessions, where
+	// attaching would nest sessions.
+	Inside() bool
+	// DetachKeys describes the keys detaching from a session.
+	DetachKeys() string
+	Sessions(ctx context.Context) ([]muxSession, error)
+	HasSession(ctx context.Context, name string) bool
+	// NewSession starts the detached session name in dir, running command
+	// when one is given.
+	NewSession(ctx context.Context, name, dir string, command ...string) error
+	// NewWindow runs command in dir in a new window of the session name.
+	NewWindow(ctx context.Context, name, dir string, command ...string) error
+	// AttachCommand returns the command attaching the terminal to target.
+	AttachCommand(target string) *exec.Cmd
+	KillSession(ctx context.Context, name string) error
+}
+
+// sessionSwitcher is a multiplexer that can move the terminal commandy runs
+// in, see Inside, to another session.
+type sessionSwitcher interface {
+	Switch(ctx context.Context, target string) error
+}
+
+// sessionRenamer is a multiplexer that can rename sessions.
+type sessionRenamer interface {
+	RenameSession(ctx context.Context, name, newName string) error
+}
+
+// muxSession is a session of a multiplexer. What the multiplexer doesn't
+// report is left zero.
+type muxSession struct {
+	Name     string
+	Windows  int
+	Attached int // Clients attached to the session
+	Created  time.Time
+	Activity time.Time
+	Path     string // Working directory of new windows
+	Current  bool   // The session this process runs in, if the multiplexer tells
+}
+
+// multiplexers are the supported multiplexers, in the order sessionMux
+// prefers them.
+func multiplexers() []multiplexer {
+	return []multiplexer{tmuxMux{client: tmuxClient()}, zellijMux{}, screenMux{}}
+}
+
+// sessionMux returns the multiplexer for project sessions: the one named by
+// cfg.Multiplexer if it is installed, otherwise the first installed of
+// tmux, zellij and GNU screen. It returns nil when none is installed. It
+// looks each one up on the PATH, so the model resolves it once, into
+// model.mux, when commandy starts.
+func sessionMux() multiplexer {
+	for _, mux := range multiplexers() {
+		if mux.Name() == cfg.Multiplexer && mux.Available() {
+			return mux
+		}
+	}
+	for _, mux := range multiplexers() {
+		if mux.Available() {
+			return mux
+		}
+	}
+	return nil
+}
+
+// tmuxMux keeps sessions in tmux, on the server of cfg.Tmux.
+type tmuxMux struct {
+	client tmux.Client
+}
+
+func (t tmuxMux) Name() string     { return "tmux" }
+func (t tmuxMux) Available() bool  { return tmux.Available() }
+func (t tmuxMux) Inside() bool     { return t.client.Inside() }
+func (tmuxMux) DetachKeys() string { return "prefix d" }
+
+func (t tmuxMux) Sessions(ctx context.Context) ([]muxSession, error) {
+	list, err := t.client.Sessions(ctx)
+	var sessions []muxSession
+	for _, s := range list {
+		sessions = append(sessions, muxSession{
+			Name:     s.Name,
+			Windows:  s.Windows,
+			Attached: s.Attached,
+			Created:  s.Created,
+			Activity: s.Activity,
+			Path:     s.Path,
+		})
+	}
+	return sessions, err
+}
+
+func (t tmuxMux) HasSession(ctx context.Context, name string) bool {
+	return t.client.HasSession(ctx, name)
+}
+
+func (t tmuxMux) NewSession(ctx context.Context, name, dir string, command ...string) error {
+	_, err := t.client.NewSession(ctx, name, dir, command...)
+	return err
+}
+
+func (t tmuxMux) NewWindow(ctx context.Context, name, dir string, command ...string) error {
+	_, err := t.client.NewWindow(ctx, name, dir, command...)
+	return err
+}
+
+func (t tmuxMux) Switch(ctx context.Context, target string) error {
+	return t.client.SwitchClient(ctx, target)
+}
+
+func (t tmuxMux) AttachCommand(target string) *exec.Cmd {
+	return t.client.AttachCommand(target)
+}
+
+func (t tmuxMux) KillSession(ctx context.Context, name string) error {
+	return t.client.KillSession(ctx, name)
+}
+
+func (t tmuxMux) RenameSession(ctx context.Context, name, newName string) error {
+	return t.client.RenameSession(ctx, name, newName)
+}
+
+// zellijMux keeps sessions in zellij.
+type zellijMux struct{}
+
+func (zellijMux) Name() string       { return "zellij" }
+func (zellijMux) Available() bool    { return zellij.Available() }
+func (zellijMux) Inside() bool       { return zellij.Inside() }
+func (zellijMux) DetachKeys() string { return "ctrl+o d" }
+
+func (zellijMux) Sessions(ctx context.Context) ([]muxSession, error) {
+	list, err := zellij.Sessions(ctx)
+	var sessions []muxSession
+	for _, s := range list {
+		sessions = append(sessions, muxSession{Name: s.Name, Created: s.Created, Current: s.Current})
+	}
+	return sessions, err
+}
+
+func (zellijMux) HasSession(ctx context.Context, name string) bool {
+	return zellij.HasSession(ctx, name)
+}
+
+func (z zellijMux) NewSession(ctx context.Context, name, dir string, command ...string) error {
+	if err := zellij.NewSession(ctx, name, dir); err != nil {
+		return err
+	}
+	if len(command) == 0 {
+		return nil
+	}
+	return z.NewWindow(ctx, name, dir, command...)
+}
+
+func (zellijMux) NewWindow(ctx context.Context, name, dir string, command ...string) error {
+	return zellij.Run(ctx, name, dir, command...)
+}
+
+func (zellijMux) AttachCommand(target string) *exec.Cmd {
+	return zellij.AttachCommand(target)
+}
+
+func (zellijMux) KillSession(ctx context.Context, name string) error {
+	return zellij.KillSession(ctx, name)
+}
+
+// screenMux keeps sessions in GNU screen. Screen tells sessions apart by
+// ID, "<pid>.<name>", so names are looked up first.
+type screenMux struct{}
+
+func (screenMux) Name() string       { return "screen" }
+func (screenMux) Available() bool    { return screen.Available() }
+func (screenMux) Inside() bool       { return screen.Inside() }
+func (screenMux) DetachKeys() string { return "ctrl+a d" }
+
+func (screenMux) Sessions(ctx context.Context) ([]muxSession, error) {
+	list, err := screen.Sessions(ctx)
+	var sessions []muxSession
+	for _, s := range list {
+		attached := 0
+		if s.Attached {
+			attached = 1
+		}
+		sessions = append(sessions, muxSession{
+			Name:     s.Name,
+			Attached: attached,
+			Current:  os.Getenv("STY") == s.ID,
+		})
+	}
+	return sessions, err
+}
+
+// id returns the ID of the session name.
+func (screenMux) id(ctx context.Context, name string) (string, error) {
+	sessions, err := screen.Sessions(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range sessions {
+		if s.Name == name {
+			return s.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no screen session named %s", name)
+}
+
+func (s screenMux) HasSession(ctx context.Context, name string) bool {
+	_, err := s.id(ctx, name)
+	return err == nil
+}
+
+func (screenMux) NewSession(ctx context.Context, name, dir string, command ...string) error {
+	return screen.NewSession(ctx, name, dir, command...)
+}
+
+func (s screenMux) NewWindow(ctx context.Context, name, dir string, command ...string) error {
+	id, err := s.id(ctx, name)
+	if err != nil {
+		return err
+	}
+	return screen.NewWindow(ctx, id, dir, command...)
+}
+
+func (s screenMux) AttachCommand(target string) *exec.Cmd {
+	if id, err := s.id(context.Background(), target); err == nil {
+		target = id
+	}
+	return screen.AttachCommand(target)
+}
+
+func (s screenMux) KillSession(ctx context.Context, name string) error {
+	id, err := s.id(ctx, name)
+	if err != nil {
+		return err
+	}
+	return screen.KillSession(ctx, id)
+}
+
+func (s screenMux) RenameSession(ctx context.Context, name, newName string) error {
+	id, err := s.id(ctx, name)
+	if err != nil {
+		return err
+	}
+	return screen.RenameSession(ctx, id, newName)
+}
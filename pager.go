@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	matchStyle        = lipgloss.NewStyle().Background(yellow).Foreground(lipgloss.Color("0"))
+	currentMatchStyle = lipgloss.NewStyle().Background(magenta).Foreground(white).Bold(true)
+)
+
+// pager shows command output as it streams in (stateRunningCommand) and
+// once the command has finished (statePager).
+type pager struct {
+	viewport viewport.Model
+	title    string
+	lines    []string // Output lines, ANSI colors preserved
+	plain    []string // Output lines with ANSI sequences stripped, for search and save
+	cr       bool     // The last line ended with a carriage return and is replaced by the next
+	returnTo menuState
+
+	running    bool
+	cancelling bool
+	started    time.Time
+	elapsed    time.Duration // Set once the command has finished
+	err        error
+
+	input   textinput.Model
+	prompt  string // "search" or "save" while input is active
+	query   string
+	matches []int // Line numbers matching query
+	match   int   // Index into matches of the current match
+	status  string
+}
+
+// newPager returns a pager for a command that has just started.
+func newPager(title string, returnTo menuState, width, height int) pager {
+	p := pager{
+		viewport: viewport.New(width, pagerHeight(height)),
+		title:    title,
+		returnTo: returnTo,
+		running:  true,
+		started:  time.Now(),
+		input:    textinput.New(),
+	}
+	p.viewport.SetHorizontalStep(4)
+	p.input.CharLimit = 256
+	return p
+}
+
+// appendOutput adds streamed lines, following the output unless the user has
+// scrolled up.
+func (p *pager) appendOutput(lines []outputLine) {
+	if len(lines) == 0 {
+		return
+	}
+	follow := p.viewport.AtBottom()
+	for _, line := range lines {
+		if p.cr && len(p.lines) > 0 {
+			p.lines = p.lines[:len(p.lines)-1]
+			p.plain = p.plain[:len(p.plain)-1]
+		}
+		p.lines = append(p.lines, line.text)
+		p.plain = append(p.plain, ansi.Strip(line.text))
+		p.cr = line.cr
+	}
+	if p.query != "" {
+		p.findMatches()
+	}
+	p.render()
+	if follow {
+		p.viewport.GotoBottom()
+	}
+}
+
+// finish records the command's result.
+func (p *pager) finish(err error) {
+	p.running = false
+	p.cancelling = false
+	p.elapsed = time.Since(p.started)
+	p.err = err
+	if len(p.lines) == 0 {
+		p.lines = []string{dimStyle.Render("(no output)")}
+		p.plain = []string{"(no output)"}
+		p.render()
+	}
+}
+
+// result describes how the command ended, e.g. "exit 0" or the error.
+func (p pager) result() string {
+	if errors.Is(p.err, context.Canceled) {
+		return "cancelled"
+	}
+	if code := exitCode(p.err); code >= 0 {
+		return fmt.Sprintf("exit %d", code)
+	}
+	return p.err.Error()
+}
+
+// pagerHeight is the number of output lines shown; the rest of the screen
+// holds the title, status and help lines.
+func pagerHeight(height int) int {
+	return max(height-5, 3)
+}
+
+func (p *pager) setSize(width, height int) {
+	p.viewport.Width = width
+	p.viewport.Height = pagerHeight(height)
+}
+
+// render sets the viewport content, highlighting search matches. Lines with
+// a match are shown without their original colors so the match stands out.
+func (p *pager) render() {
+	if p.query == "" {
+		p.viewport.SetContent(strings.Join(p.lines, "\n"))
+		return
+	}
+
+	re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(p.query))
+	current := -1
+	if len(p.matches) > 0 {
+		current = p.matches[p.match]
+	}
+
+	rendered := make([]string, len(p.lines))
+	for i, line := range p.lines {
+		if !re.MatchString(p.plain[i]) {
+			rendered[i] = line
+			continue
+		}
+		style := matchStyle
+		if i == current {
+			style = currentMatchStyle
+		}
+		rendered[i] = re.ReplaceAllStringFunc(p.plain[i], func(s string) string {
+			return style.Render(s)
+		})
+	}
+	p.viewport.SetContent(strings.Join(rendered, "\n"))
+}
+
+// search finds the lines containing query (case-insensitive) and jumps to
+// the first match.
+func (p *pager) search(query string) {
+	p.query = query
+	p.match = 0
+	p.findMatches()
+
+	switch {
+	case query == "":
+		p.status = ""
+	case len(p.matches) == 0:
+		p.status = fmt.Sprintf("No matches for %q", query)
+	}
+	p.render()
+	p.showMatch()
+}
+
+// nextMatch moves to the next (delta 1) or previous (delta -1) match.
+func (p *pager) nextMatch(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.match = (p.match + delta + len(p.matches)) % len(p.matches)
+	p.render()
+	p.showMatch()
+}
+
+// findMatches finds the lines containing the query (case-insensitive).
+func (p *pager) findMatches() {
+	p.matches = nil
+	if p.query != "" {
+		q := strings.ToLower(p.query)
+		for i, line := range p.plain {
+			if strings.Contains(strings.ToLower(line), q) {
+				p.matches = append(p.matches, i)
+			}
+		}
+	}
+	if p.match >= len(p.matches) {
+		p.match = max(len(p.matches)-1, 0)
+	}
+}
+
+func (p *pager) showMatch() {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.viewport.SetYOffset(p.matches[p.match] - p.viewport.Height/2)
+	p.status = fmt.Sprintf("Match %d of %d", p.match+1, len(p.matches))
+}
+
+// text returns the output without ANSI sequences.
+func (p pager) text() string {
+	return strings.Join(p.plain, "\n") + "\n"
+}
+
+// copyToClipboard copies the output using the system clipboard, falling back
+// to an OSC 52 escape sequence (which also works over ssh) when no clipboard
+// tool is available.
+func (p *pager) copyToClipboard() {
+	text := p.text()
+	if err := clipboard.WriteAll(text); err != nil {
+		if _, err := osc52.New(text).WriteTo(os.Stderr); err != nil {
+			p.status = fmt.Sprintf("Copy failed: %v", err)
+			return
+		}
+	}
+	p.status = fmt.Sprintf("Copied %d lines to the clipboard", len(p.plain))
+}
+
+func (p *pager) save(path string) {
+	path = expandPath(strings.TrimSpace(path))
+	if path == "" {
+		p.status = "No file name given"
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		p.status = fmt.Sprintf("Save failed: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, []byte(p.text()), 0644); err != nil {
+		p.status = fmt.Sprintf("Save failed: %v", err)
+		return
+	}
+	p.status = fmt.Sprintf("Saved to %s", path)
+}
+
+// defaultSavePath suggests a file name for saving the output.
+func (p pager) defaultSavePath() string {
+	slug := strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(p.title), "-"), "-")
+	if slug == "" {
+		slug = "output"
+	}
+	name := fmt.Sprintf("commandy-%s-%s.log", slug, time.Now().Format("20060102-150405"))
+	return filepath.Join("~", name)
+}
+
+func (p *pager) startInput(prompt, value string) tea.Cmd {
+	p.prompt = prompt
+	p.status = ""
+	p.input.Reset()
+	p.input.SetValue(value)
+	p.input.CursorEnd()
+	return p.input.Focus()
+}
+
+func (p pager) Update(msg tea.KeyMsg) (pager, tea.Cmd) {
+	if p.prompt != "" {
+		switch msg.String() {
+		case "esc":
+			p.prompt = ""
+			p.input.Blur()
+			return p, nil
+		case "enter":
+			value := p.input.Value()
+			switch p.prompt {
+			case "search":
+				p.search(value)
+			case "save":
+				p.save(value)
+			}
+			p.prompt = ""
+			p.input.Blur()
+			return p, nil
+		}
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		return p, cmd
+	}
+
+	switch msg.String() {
+	case "/":
+		return p, p.startInput("search", "")
+	case "n":
+		p.nextMatch(1)
+		return p, nil
+	case "N":
+		p.nextMatch(-1)
+		return p, nil
+	case "c", "y":
+		p.copyToClipboard()
+		return p, nil
+	case "s":
+		return p, p.startInput("save", p.defaultSavePath())
+	case "g", "home":
+		p.viewport.GotoTop()
+		return p, nil
+	case "G", "end":
+		p.viewport.GotoBottom()
+		return p, nil
+	}
+
+	var cmd tea.Cmd
+	p.viewport, cmd = p.viewport.Update(msg)
+	return p, cmd
+}
+
+// View renders the pager; spin is the spinner frame shown while the command
+// is running.
+func (p pager) View(spin string) string {
+	var s strings.Builder
+
+	var status string
+	switch {
+	case p.cancelling:
+		status = spin + errorStyle.Render("cancelling...")
+	case p.running:
+		status = spin + subtitleStyle.Render("running "+formatElapsed(time.Since(p.started)))
+	case p.err != nil:
+		status = errorStyle.Render(fmt.Sprintf("✗ %s • %s", p.result(), formatElapsed(p.elapsed)))
+	default:
+		status = successStyle.Render(fmt.Sprintf("✓ %s • %s", p.result(), formatElapsed(p.elapsed)))
+	}
+	position := dimStyle.Render(fmt.Sprintf("%d lines • %3.0f%%", len(p.lines), p.viewport.ScrollPercent()*100))
+	s.WriteString(headerStyle.Render(p.title) + "  " + status + "  " + position + "\n\n")
+
+	s.WriteString(p.viewport.View())
+	s.WriteString("\n\n")
+
+	switch {
+	case p.prompt == "search":
+		s.WriteString(subtitleStyle.Render("/") + p.input.View())
+	case p.prompt == "save":
+		s.WriteString(subtitleStyle.Render("Save to: ") + p.input.View())
+	case p.status != "":
+		s.WriteString(subtitleStyle.Render(p.status))
+	case p.running:
+		s.WriteString(dimStyle.Render("↑/↓ scroll • / search • n/N next/prev • c copy • x cancel • esc hide (keeps running)"))
+	default:
+		s.WriteString(dimStyle.Render("↑/↓ scroll • ←/→ pan • / search • n/N next/prev • c copy • s save • q/esc back"))
+	}
+	return s.String()
+}
+
+// updatePager handles keys in statePager.
+func (m model) updatePager(msg tea.KeyMsg) (model, tea.Cmd) {
+	if m.job.pager.prompt == "" {
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "q", "esc":
+			m.state = m.job.pager.returnTo
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.job.pager, cmd = m.job.pager.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,181 @@
+// Package portauthority is a client for the Port Authority API, which keeps
+// track of the ports assigned to each project's services so they don't
+// collide across machines.
+package portauthority
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Registration is a port assigned to a project's service.
+type Registration struct {
+	ID        string    `json:"id"`
+	Project   string    `json:"project"`
+	Service   string    `json:"service"`
+	Port      int       `json:"port"`
+	Host      string    `json:"host"`
+	LastSeen  time.Time `json:"lastSeen"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RegisterRequest asks for a port for a project's service. A zero Port lets
+// the server pick a free one.
+type RegisterRequest struct {
+	Project string `json:"project"`
+	Service string `json:"service"`
+	Port    int    `json:"port,omitempty"`
+	Host    string `json:"host,omitempty"`
+}
+
+// APIError is returned for responses with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("port authority: %s", http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("port authority: %s (%d)", e.Message, e.StatusCode)
+}
+
+// ConflictError is returned when a port is already registered to another
+// service.
+type ConflictError struct {
+	Port     int
+	Existing *Registration // Registration holding the port, if the server reported it
+	Message  string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Existing != nil {
+		return fmt.Sprintf("port %d is already registered to %s/%s on %s",
+			e.Existing.Port, e.Existing.Project, e.Existing.Service, e.Existing.Host)
+	}
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("port %d is already registered", e.Port)
+}
+
+// Client talks to the Port Authority API at BaseURL, e.g.
+// "http://zynx.lan:3030/api".
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// New returns a client for the API at baseURL.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// List returns every registration.
+func (c *Client) List(ctx context.Context) ([]Registration, error) {
+	var regs []Registration
+	err := c.do(ctx, http.MethodGet, "/ports", nil, &regs, 0)
+	return regs, err
+}
+
+// ProjectPorts returns the registrations of a project.
+func (c *Client) ProjectPorts(ctx context.Context, project string) ([]Registration, error) {
+	var regs []Registration
+	err := c.do(ctx, http.MethodGet, "/ports?project="+url.QueryEscape(project), nil, &regs, 0)
+	return regs, err
+}
+
+// Register assigns a port to a project's service.
+func (c *Client) Register(ctx context.Context, req RegisterRequest) (Registration, error) {
+	var reg Registration
+	err := c.do(ctx, http.MethodPost, "/ports", req, &reg, req.Port)
+	return reg, err
+}
+
+// Update moves a registration to a different port.
+func (c *Client) Update(ctx context.Context, id string, port int) (Registration, error) {
+	var reg Registration
+	body := struct {
+		Port int `json:"port"`
+	}{port}
+	err := c.do(ctx, http.MethodPatch, "/ports/"+url.PathEscape(id), body, &reg, port)
+	return reg, err
+}
+
+// Release removes a registration, freeing its port.
+func (c *Client) Release(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/ports/"+url.PathEscape(id), nil, nil, 0)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out. port is the port being claimed, used to describe
+// conflicts.
+func (c *Client) do(ctx context.Context, method, path string, in, out any, port int) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return responseError(resp.StatusCode, data, port)
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("port authority: decoding %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// errorBody is the JSON body of an error response.
+type errorBody struct {
+	Error    string        `json:"error"`
+	Conflict *Registration `json:"conflict"`
+}
+
+func responseError(status int, data []byte, port int) error {
+	var body errorBody
+	if json.Unmarshal(data, &body) != nil {
+		body.Error = strings.TrimSpace(string(data))
+	}
+	if status == http.StatusConflict {
+		return &ConflictError{Port: port, Existing: body.Conflict, Message: body.Error}
+	}
+	return &APIError{StatusCode: status, Message: body.Error}
+}
@@ -0,0 +1,213 @@
+package portauthority
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// request is what the stand-in server received.
+type request struct {
+	method, path, query string
+	contentType         string
+	body                map[string]any
+}
+
+// standIn starts a Port Authority stand-in answering every request with
+// status and body, and returns a client for it and the requests it got.
+func standIn(t *testing.T, status int, body string) (*Client, *[]request) {
+	t.Helper()
+	var got []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{
+			method:      r.Method,
+			path:        r.URL.EscapedPath(),
+			query:       r.URL.RawQuery,
+			contentType: r.Header.Get("Content-Type"),
+		}
+		data, _ := io.ReadAll(r.Body)
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &req.body); err != nil {
+				t.Errorf("%s %s: body %q isn't JSON: %v", r.Method, r.URL.Path, data, err)
+			}
+		}
+		got = append(got, req)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return New(srv.URL + "/api/"), &got
+}
+
+const apiReg = `{"id":"r1","project":"web","service":"vite","port":5173,"host":"dev",
+	"lastSeen":"2026-10-16T12:00:00Z","createdAt":"2026-10-01T09:30:00Z"}`
+
+var wantReg = Registration{
+	ID:        "r1",
+	Project:   "web",
+	Service:   "vite",
+	Port:      5173,
+	Host:      "dev",
+	LastSeen:  time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+	CreatedAt: time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC),
+}
+
+func checkRequest(t *testing.T, got []request, method, path, query string) request {
+	t.Helper()
+	if len(got) != 1 {
+		t.Fatalf("server got %d requests, want 1", len(got))
+	}
+	r := got[0]
+	if r.method != method || r.path != path || r.query != query {
+		t.Errorf("request = %s %s?%s, want %s %s?%s", r.method, r.path, r.query, method, path, query)
+	}
+	return r
+}
+
+func TestList(t *testing.T) {
+	c, got := standIn(t, http.StatusOK, "["+apiReg+"]")
+	regs, err := c.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := checkRequest(t, *got, http.MethodGet, "/api/ports", "")
+	if r.body != nil || r.contentType != "" {
+		t.Errorf("GET sent body %v with Content-Type %q", r.body, r.contentType)
+	}
+	if len(regs) != 1 || regs[0] != wantReg {
+		t.Errorf("List() = %+v, want [%+v]", regs, wantReg)
+	}
+}
+
+func TestProjectPorts(t *testing.T) {
+	c, got := standIn(t, http.StatusOK, "["+apiReg+"]")
+	regs, err := c.ProjectPorts(context.Background(), "my app&co")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRequest(t, *got, http.MethodGet, "/api/ports", "project=my+app%26co")
+	if len(regs) != 1 || regs[0] != wantReg {
+		t.Errorf("ProjectPorts() = %+v, want [%+v]", regs, wantReg)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	c, got := standIn(t, http.StatusCreated, apiReg)
+	reg, err := c.Register(context.Background(), RegisterRequest{Project: "web", Service: "vite", Host: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := checkRequest(t, *got, http.MethodPost, "/api/ports", "")
+	if r.contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", r.contentType)
+	}
+	// A zero port is left for the server to pick
+	want := map[string]any{"project": "web", "service": "vite", "host": "dev"}
+	if len(r.body) != len(want) {
+		t.Errorf("body = %v, want %v", r.body, want)
+	}
+	for k, v := range want {
+		if r.body[k] != v {
+			t.Errorf("body[%s] = %v, want %v", k, r.body[k], v)
+		}
+	}
+	if reg != wantReg {
+		t.Errorf("Register() = %+v, want %+v", reg, wantReg)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	c, got := standIn(t, http.StatusOK, apiReg)
+	reg, err := c.Update(context.Background(), "r/1", 5173)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := checkRequest(t, *got, http.MethodPatch, "/api/ports/r%2F1", "")
+	if len(r.body) != 1 || r.body["port"] != 5173.0 {
+		t.Errorf("body = %v, want {port: 5173}", r.body)
+	}
+	if reg != wantReg {
+		t.Errorf("Update() = %+v, want %+v", reg, wantReg)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	c, got := standIn(t, http.StatusNoContent, "")
+	if err := c.Release(context.Background(), "r1"); err != nil {
+		t.Fatal(err)
+	}
+	r := checkRequest(t, *got, http.MethodDelete, "/api/ports/r1", "")
+	if r.body != nil {
+		t.Errorf("DELETE sent body %v", r.body)
+	}
+}
+
+func TestConflict(t *testing.T) {
+	tests := []struct {
+		name, body string
+		want       string
+	}{
+		{"with registration", `{"error":"port taken","conflict":` + apiReg + `}`,
+			"port 5173 is already registered to web/vite on dev"},
+		{"with message", `{"error":"port taken"}`, "port taken"},
+		{"without body", ``, "port 3000 is already registered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := standIn(t, http.StatusConflict, tt.body)
+			_, err := c.Register(context.Background(), RegisterRequest{Project: "api", Service: "http", Port: 3000})
+			var conflict *ConflictError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("err = %#v, want a *ConflictError", err)
+			}
+			if conflict.Port != 3000 {
+				t.Errorf("Port = %d, want 3000", conflict.Port)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("err = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"JSON", http.StatusNotFound, `{"error":"no such registration"}`, "port authority: no such registration (404)"},
+		{"text", http.StatusBadGateway, "upstream down\n", "port authority: upstream down (502)"},
+		{"empty", http.StatusInternalServerError, "", "port authority: Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := standIn(t, tt.status, tt.body)
+			err := c.Release(context.Background(), "r1")
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %#v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, tt.status)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("err = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	c, _ := standIn(t, http.StatusOK, "not json")
+	if _, err := c.List(context.Background()); err == nil {
+		t.Error("List() of a non-JSON response succeeded")
+	}
+}
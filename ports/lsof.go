@@ -0,0 +1,111 @@
+package ports
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// LsofScan returns the listening TCP sockets using lsof, for systems
+// without a Linux /proc.
+func LsofScan() ([]Listener, error) {
+	output, err := exec.Command("lsof", "-nP", "-iTCP", "-sTCP:LISTEN", "-F", "pcn").Output()
+	// lsof exits 1 when nothing matches
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && len(output) == 0) {
+		return nil, err
+	}
+	listeners := parseLsof(string(output))
+
+	var pids []string
+	seen := make(map[int]bool)
+	for _, l := range listeners {
+		if !seen[l.PID] {
+			seen[l.PID] = true
+			pids = append(pids, strconv.Itoa(l.PID))
+		}
+	}
+	if len(pids) > 0 {
+		output, _ := exec.Command("lsof", "-a", "-d", "cwd", "-p", strings.Join(pids, ","), "-F", "pn").Output()
+		cwds := parseLsofCwd(string(output))
+		for i := range listeners {
+			listeners[i].Cwd = cwds[listeners[i].PID]
+		}
+	}
+
+	sortListeners(listeners)
+	return listeners, nil
+}
+
+// parseLsof parses the output of `lsof -F pcn`: a "p<pid>" line and a
+// "c<command>" line per process, then an "n<addr>:<port>" line per socket.
+func parseLsof(output string) []Listener {
+	var listeners []Listener
+	var pid int
+	var command string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		value := line[1:]
+		switch line[0] {
+		case 'p':
+			pid, _ = strconv.Atoi(value)
+			command = ""
+		case 'c':
+			command = value
+		case 'n':
+			host, portStr, err := net.SplitHostPort(value)
+			if err != nil {
+				continue
+			}
+			port, err := strconv.Atoi(portStr)
+			if err != nil {
+				continue
+			}
+			network := "tcp"
+			if strings.Contains(host, ":") {
+				network = "tcp6"
+			}
+			key := strconv.Itoa(pid) + " " + value
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			listeners = append(listeners, Listener{
+				Network: network,
+				Addr:    host,
+				Port:    port,
+				PID:     pid,
+				Process: command,
+			})
+		}
+	}
+	return listeners
+}
+
+// parseLsofCwd parses the output of `lsof -a -d cwd -F pn` into a map of
+// PID to working directory.
+func parseLsofCwd(output string) map[int]string {
+	cwds := make(map[int]string)
+	var pid int
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			pid, _ = strconv.Atoi(line[1:])
+		case 'n':
+			cwds[pid] = line[1:]
+		}
+	}
+	return cwds
+}
@@ -0,0 +1,116 @@
+// Package ports lists the TCP ports that are listening on this machine and
+// the processes that own them.
+//
+// On Linux the information comes from /proc (see ProcScanner); elsewhere it
+// comes from lsof.
+package ports
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Listener is a listening TCP socket, paired with one process holding it.
+// A socket shared by several processes (e.g. after fork) is reported once
+// per process.
+type Listener struct {
+	Network string // "tcp" or "tcp6"
+	Addr    string // Local address, e.g. "0.0.0.0", "::1", or "*" from lsof
+	Port    int
+	Inode   uint64 // Socket inode, Linux only
+	PID     int    // 0 when the owning process can't be determined
+	Process string // Process name
+	Cwd     string // Working directory of the process, if readable
+}
+
+// Scan returns the listening TCP sockets on this machine, sorted by port.
+func Scan() ([]Listener, error) {
+	if runtime.GOOS == "linux" {
+		return ProcScanner{Root: "/proc"}.Scan()
+	}
+	return LsofScan()
+}
+
+// Range is an inclusive range of ports. The zero Range contains every port.
+type Range struct {
+	Lo, Hi int
+}
+
+// ParseRange parses "3000", "3000-3999" or "" (every port).
+func ParseRange(s string) (Range, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Range{}, nil
+	}
+	lo, hi, isRange := strings.Cut(s, "-")
+	r := Range{}
+	var err error
+	if r.Lo, err = ParsePort(lo); err != nil {
+		return Range{}, err
+	}
+	r.Hi = r.Lo
+	if isRange {
+		if r.Hi, err = ParsePort(hi); err != nil {
+			return Range{}, err
+		}
+	}
+	if r.Lo > r.Hi {
+		return Range{}, fmt.Errorf("invalid port range %q: %d is greater than %d", s, r.Lo, r.Hi)
+	}
+	return r, nil
+}
+
+// ParsePort parses a single port number.
+func ParsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%q is not a valid port (1-65535)", s)
+	}
+	return port, nil
+}
+
+// Contains reports whether port is in the range.
+func (r Range) Contains(port int) bool {
+	if r == (Range{}) {
+		return true
+	}
+	return port >= r.Lo && port <= r.Hi
+}
+
+func (r Range) String() string {
+	switch {
+	case r == (Range{}):
+		return "all ports"
+	case r.Lo == r.Hi:
+		return fmt.Sprintf("port %d", r.Lo)
+	default:
+		return fmt.Sprintf("ports %d-%d", r.Lo, r.Hi)
+	}
+}
+
+// Filter returns the listeners whose port is in r.
+func Filter(listeners []Listener, r Range) []Listener {
+	var filtered []Listener
+	for _, l := range listeners {
+		if r.Contains(l.Port) {
+			filtered = append(filtered, l)
+		}
+	}
+	return filtered
+}
+
+func sortListeners(listeners []Listener) {
+	sort.Slice(listeners, func(i, j int) bool {
+		a, b := listeners[i], listeners[j]
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		if a.PID != b.PID {
+			return a.PID < b.PID
+		}
+		return a.Network < b.Network
+	})
+}
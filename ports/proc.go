@@ -0,0 +1,168 @@
+package ports
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tcpListen is the st value of a listening socket in /proc/net/tcp.
+const tcpListen = "0A"
+
+// ProcScanner reads listening sockets from a Linux /proc tree. Root is
+// normally "/proc" but can point at a fixture tree containing net/tcp,
+// net/tcp6 and <pid>/{comm,cwd,fd/} entries.
+type ProcScanner struct {
+	Root string
+}
+
+// Scan returns the listening TCP sockets, sorted by port. Processes whose
+// file descriptors can't be read (usually those of other users) are skipped,
+// so their sockets are reported with PID 0.
+func (s ProcScanner) Scan() ([]Listener, error) {
+	var sockets []Listener
+	for _, network := range []string{"tcp", "tcp6"} {
+		found, err := s.readNet(network)
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, found...)
+	}
+
+	owners := s.socketOwners()
+
+	var listeners []Listener
+	for _, sock := range sockets {
+		pids := owners[sock.Inode]
+		if len(pids) == 0 {
+			listeners = append(listeners, sock)
+			continue
+		}
+		for _, pid := range pids {
+			l := sock
+			l.PID = pid
+			l.Process, l.Cwd = s.process(pid)
+			listeners = append(listeners, l)
+		}
+	}
+	sortListeners(listeners)
+	return listeners, nil
+}
+
+// readNet parses /proc/net/tcp or /proc/net/tcp6. A missing tcp6 file (IPv6
+// disabled) is not an error.
+func (s ProcScanner) readNet(network string) ([]Listener, error) {
+	f, err := os.Open(filepath.Join(s.Root, "net", network))
+	if err != nil {
+		if network == "tcp6" && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var listeners []Listener
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // Header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != tcpListen {
+			continue
+		}
+		addr, port, err := parseHexAddr(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad inode %q", f.Name(), fields[9])
+		}
+		listeners = append(listeners, Listener{
+			Network: network,
+			Addr:    addr,
+			Port:    port,
+			Inode:   inode,
+		})
+	}
+	return listeners, scanner.Err()
+}
+
+// parseHexAddr parses an address such as "0100007F:0BB8". The kernel
+// prints the IP as 32-bit words in the machine's native byte order, so
+// "0100007F" is 127.0.0.1 on little-endian machines.
+func parseHexAddr(s string) (string, int, error) {
+	ipHex, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("bad address %q", s)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("bad port in %q", s)
+	}
+	raw, err := hex.DecodeString(ipHex)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("bad IP in %q", s)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.NativeEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(raw[i:]))
+	}
+	return ip.String(), int(port), nil
+}
+
+// socketOwners maps socket inodes to the PIDs holding them open.
+func (s ProcScanner) socketOwners() map[uint64][]int {
+	owners := make(map[uint64][]int)
+	entries, err := os.ReadDir(s.Root)
+	if err != nil {
+		return owners
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(s.Root, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		seen := make(map[uint64]bool)
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			inodeStr, ok := strings.CutPrefix(target, "socket:[")
+			if !ok {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(inodeStr, "]"), 10, 64)
+			if err != nil || seen[inode] {
+				continue
+			}
+			seen[inode] = true
+			owners[inode] = append(owners[inode], pid)
+		}
+	}
+	return owners
+}
+
+// process returns the name and working directory of a process.
+func (s ProcScanner) process(pid int) (name, cwd string) {
+	dir := filepath.Join(s.Root, strconv.Itoa(pid))
+	if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+		name = strings.TrimSpace(string(comm))
+	}
+	cwd, _ = os.Readlink(filepath.Join(dir, "cwd"))
+	return name, cwd
+}
@@ -0,0 +1,233 @@
+package ports
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// littleEndian reports whether the machine writes /proc/net/tcp addresses
+// the way the fixtures below do.
+func littleEndian() bool {
+	var b [2]byte
+	binary.NativeEndian.PutUint16(b[:], 1)
+	return b[0] == 1
+}
+
+const (
+	tcpHeader  = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	tcp6Header = "  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+)
+
+// procFixture is a /proc tree with:
+//
+//	node (pid 100) listening on 127.0.0.1:3000 and [::]:3000, cwd /srv/web
+//	postgres (pid 200) listening on 0.0.0.0:5432, cwd /var/lib/postgres
+//	an established connection and a socket owned by no readable process
+func procFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	write := func(name, data string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(name, target string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("net/tcp", tcpHeader+
+		"   0: 0100007F:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1001 1 0000000000000000 100 0 0 10 0\n"+
+		"   1: 00000000:1538 00000000:0000 0A 00000000:00000000 00:00000000 00000000   999        0 2001 1 0000000000000000 100 0 0 10 0\n"+
+		"   2: 0100007F:0BB8 0100007F:D431 01 00000000:00000000 00:00000000 00000000  1000        0 1002 1 0000000000000000 20 4 30 10 -1\n"+
+		"   3: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 3001 1 0000000000000000 100 0 0 10 0\n")
+	write("net/tcp6", tcp6Header+
+		"   0: 00000000000000000000000000000000:0BB8 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1003 1 0000000000000000 100 0 0 10 0\n")
+
+	write("100/comm", "node\n")
+	link("100/cwd", "/srv/web")
+	link("100/fd/0", "/dev/null")
+	link("100/fd/3", "socket:[1001]")
+	link("100/fd/4", "socket:[1002]")
+	link("100/fd/5", "socket:[1003]")
+	link("100/fd/6", "socket:[1001]") // Duplicated descriptor
+
+	write("200/comm", "postgres\n")
+	link("200/cwd", "/var/lib/postgres")
+	link("200/fd/7", "socket:[2001]")
+	link("200/fd/8", "pipe:[9999]")
+
+	write("self/comm", "commandy\n") // Not a PID, skipped
+	return root
+}
+
+func TestProcScanner(t *testing.T) {
+	if !littleEndian() {
+		t.Skip("fixture addresses are written by a little-endian kernel")
+	}
+	listeners, err := ProcScanner{Root: procFixture(t)}.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Listener{
+		{Network: "tcp", Addr: "127.0.0.1", Port: 3000, Inode: 1001, PID: 100, Process: "node", Cwd: "/srv/web"},
+		{Network: "tcp6", Addr: "::", Port: 3000, Inode: 1003, PID: 100, Process: "node", Cwd: "/srv/web"},
+		{Network: "tcp", Addr: "0.0.0.0", Port: 5432, Inode: 2001, PID: 200, Process: "postgres", Cwd: "/var/lib/postgres"},
+		{Network: "tcp", Addr: "0.0.0.0", Port: 8080, Inode: 3001},
+	}
+	if !reflect.DeepEqual(listeners, want) {
+		t.Errorf("Scan() =\n%+v\nwant\n%+v", listeners, want)
+	}
+
+	r, err := ParseRange("3000-5432")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filtered := Filter(listeners, r)
+	if len(filtered) != 3 || filtered[2].Port != 5432 {
+		t.Errorf("Filter(%v) = %+v, want the 3000 and 5432 listeners", r, filtered)
+	}
+	if got := Filter(listeners, Range{}); len(got) != len(listeners) {
+		t.Errorf("Filter(all ports) = %d listeners, want %d", len(got), len(listeners))
+	}
+}
+
+func TestProcScannerWithoutTCP6(t *testing.T) {
+	root := procFixture(t)
+	if err := os.Remove(filepath.Join(root, "net", "tcp6")); err != nil {
+		t.Fatal(err)
+	}
+	listeners, err := ProcScanner{Root: root}.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range listeners {
+		if l.Network == "tcp6" {
+			t.Errorf("Scan() without net/tcp6 returned %+v", l)
+		}
+	}
+
+	if err := os.Remove(filepath.Join(root, "net", "tcp")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (ProcScanner{Root: root}).Scan(); err == nil {
+		t.Error("Scan() without net/tcp succeeded")
+	}
+}
+
+func TestParseHexAddr(t *testing.T) {
+	if !littleEndian() {
+		t.Skip("addresses are written as by a little-endian kernel")
+	}
+	tests := []struct {
+		in       string
+		wantAddr string
+		wantPort int
+		wantErr  bool
+	}{
+		{in: "0100007F:0BB8", wantAddr: "127.0.0.1", wantPort: 3000},
+		{in: "00000000:0050", wantAddr: "0.0.0.0", wantPort: 80},
+		{in: "0101A8C0:FFFF", wantAddr: "192.168.1.1", wantPort: 65535},
+		{in: "00000000000000000000000001000000:1F90", wantAddr: "::1", wantPort: 8080},
+		{in: "00000000000000000000000000000000:0016", wantAddr: "::", wantPort: 22},
+		{in: "0000000000000000FFFF00000100007F:0BB8", wantAddr: "127.0.0.1", wantPort: 3000}, // IPv4-mapped
+		{in: "B80D0120000000000000000001000000:01BB", wantAddr: "2001:db8::1", wantPort: 443},
+		{in: "0100007F", wantErr: true},
+		{in: "0100007F:XYZ", wantErr: true},
+		{in: "0100007F:10000", wantErr: true},
+		{in: "01007F:0BB8", wantErr: true},
+		{in: "GG00007F:0BB8", wantErr: true},
+	}
+	for _, tt := range tests {
+		addr, port, err := parseHexAddr(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseHexAddr(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if addr != tt.wantAddr || port != tt.wantPort {
+			t.Errorf("parseHexAddr(%q) = %q, %d, want %q, %d", tt.in, addr, port, tt.wantAddr, tt.wantPort)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Range
+		wantErr bool
+	}{
+		{in: "", want: Range{}},
+		{in: "  ", want: Range{}},
+		{in: "3000", want: Range{3000, 3000}},
+		{in: " 3000-3999 ", want: Range{3000, 3999}},
+		{in: "1-65535", want: Range{1, 65535}},
+		{in: "3999-3000", wantErr: true},
+		{in: "0", wantErr: true},
+		{in: "65536", wantErr: true},
+		{in: "3000-", wantErr: true},
+		{in: "-3000", wantErr: true},
+		{in: "http", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRange(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRange(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRange(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRangeContains(t *testing.T) {
+	r := Range{3000, 3999}
+	for port, want := range map[int]bool{2999: false, 3000: true, 3500: true, 3999: true, 4000: false} {
+		if got := r.Contains(port); got != want {
+			t.Errorf("%v.Contains(%d) = %v, want %v", r, port, got, want)
+		}
+	}
+	if !(Range{}).Contains(1) || !(Range{}).Contains(65535) {
+		t.Error("the zero Range doesn't contain every port")
+	}
+}
+
+func TestParseLsof(t *testing.T) {
+	output := "p312\ncnode\nn127.0.0.1:3000\nn[::1]:3000\nn127.0.0.1:3000\n" +
+		"p88\ncpostgres\nn*:5432\nnnot-an-address\n\n" +
+		"p91\ncredis-server\nn[::]:6379\n"
+	want := []Listener{
+		{Network: "tcp", Addr: "127.0.0.1", Port: 3000, PID: 312, Process: "node"},
+		{Network: "tcp6", Addr: "::1", Port: 3000, PID: 312, Process: "node"},
+		{Network: "tcp", Addr: "*", Port: 5432, PID: 88, Process: "postgres"},
+		{Network: "tcp6", Addr: "::", Port: 6379, PID: 91, Process: "redis-server"},
+	}
+	if got := parseLsof(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLsof() =\n%+v\nwant\n%+v", got, want)
+	}
+	if got := parseLsof(""); got != nil {
+		t.Errorf("parseLsof(\"\") = %+v, want nil", got)
+	}
+}
+
+func TestParseLsofCwd(t *testing.T) {
+	output := "p312\nfcwd\nn/Users/me/Projects/web\np88\nfcwd\nn/usr/local/var/postgres\n"
+	want := map[int]string{312: "/Users/me/Projects/web", 88: "/usr/local/var/postgres"}
+	if got := parseLsofCwd(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLsofCwd() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"commandy/git"
+)
+
+// projectRoots returns the directories searched for projects: projectsDir,
+// then the extra roots from the config, without duplicates.
+func projectRoots() []string {
+	roots := []string{filepath.Clean(projectsDir)}
+	for _, root := range cfg.Projects.Roots {
+		root = filepath.Clean(expandPath(root))
+		if !slices.Contains(roots, root) {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// rootLabel returns the prefix of the names of projects found under root.
+// Projects in projectsDir have none, so their names and tmux sessions stay
+// the same when roots are added; the others are prefixed with the root's
+// directory name, or its whole path when another root has the same name.
+func rootLabel(root string) string {
+	if root == filepath.Clean(projectsDir) {
+		return ""
+	}
+	for _, other := range projectRoots() {
+		if other != root && filepath.Base(other) == filepath.Base(root) {
+			return root
+		}
+	}
+	return filepath.Base(root)
+}
+
+// projectRootLabel returns the rootLabel of the root containing the project
+// at dir.
+func projectRootLabel(dir string) string {
+	for _, root := range projectRoots() {
+		if strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			return rootLabel(root)
+		}
+	}
+	return ""
+}
+
+// discoverProjects finds the projects under every root, grouped by root. A
+// git repository is a project and isn't searched any further. Other
+// directories are searched up to cfg.Projects.Depth levels down, and are
+// projects themselves when nothing below them is. The packages of a
+// workspace are listed after its root, see workspacePackages; other
+// subdirectories of a project aren't packages, however many of them hold a
+// package.json. When require is set, only projects and packages containing
+// all of the required files are listed.
+func discoverProjects(require []string) (names, paths []string) {
+	for _, root := range projectRoots() {
+		prefix := rootLabel(root)
+		var found []string
+		findProjects(root, root, cfg.Projects.Depth, &found)
+		for _, dir := range found {
+			rel, _ := filepath.Rel(root, dir)
+			name := path.Join(prefix, filepath.ToSlash(rel))
+			if len(require) == 0 || hasFiles(dir, require) {
+				names = append(names, name)
+				paths = append(paths, dir)
+			}
+
+			if pkgs, ok := workspacePackages(dir); ok {
+				for _, pkg := range pkgs {
+					pkgPath := filepath.Join(dir, filepath.FromSlash(pkg))
+					if len(require) == 0 || hasFiles(pkgPath, require) {
+						names = append(names, name+"/"+pkg)
+						paths = append(paths, pkgPath)
+					}
+				}
+			}
+		}
+	}
+	return names, paths
+}
+
+// findProjects appends the projects in dir to found, searching depth levels
+// down. It reports whether it found any.
+func findProjects(root, dir string, depth int, found *[]string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	any := false
+	for _, entry := range entries {
+		sub := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() || ignoredDir(root, sub) {
+			continue
+		}
+		if depth > 1 && !git.IsRepo(sub) && findProjects(root, sub, depth-1, found) {
+			any = true
+			continue
+		}
+		*found = append(*found, sub)
+		any = true
+	}
+	return any
+}
+
+// ignoredDir reports whether dir matches one of the ignore globs, either by
+// name or by its path relative to root.
+func ignoredDir(root, dir string) bool {
+	rel, _ := filepath.Rel(root, dir)
+	for _, pattern := range cfg.Projects.Ignore {
+		if ok, _ := filepath.Match(pattern, filepath.Base(dir)); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.ToSlash(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// projectInfoTTL is how long detected project information is reused before
+// it is refreshed in the background.
+const projectInfoTTL = 30 * time.Second
+
+// stackMarkers are the files that identify a project's languages and tools,
+// in badge order.
+var stackMarkers = []struct {
+	file  string
+	badge string
+	color lipgloss.Color
+}{
+	{"package.json", "node", green},
+	{"go.mod", "go", cyan},
+	{"Cargo.toml", "rust", red},
+	{"pyproject.toml", "py", yellow},
+	{"Gemfile", "ruby", red},
+	{"docker-compose.yml", "compose", blue},
+	{"prisma/schema.prisma", "prisma", magenta},
+}
+
+// projectInfo is what commandy knows about a project directory beyond its
+// name. It is shown as badges in the project lists.
+type projectInfo struct {
+	stacks  []string // Badges of the stackMarkers found
+	dirty   bool     // Uncommitted changes, including untracked files
+	checked time.Time
+}
+
+type projectInfoMsg struct {
+	infos map[string]projectInfo // By project path
+}
+
+// detectProject looks for stack markers and git changes in dir.
+func detectProject(ctx context.Context, dir string) projectInfo {
+	info := projectInfo{checked: time.Now()}
+	for _, marker := range stackMarkers {
+		if _, err := os.Stat(filepath.Join(dir, marker.file)); err == nil {
+			info.stacks = append(info.stacks, marker.badge)
+		}
+	}
+	if git.IsRepo(dir) {
+		info.dirty, _ = git.IsDirty(ctx, dir)
+	}
+	return info
+}
+
+// loadProjectInfo detects the listed projects whose information is missing
+// or older than projectInfoTTL, cfg.Git.Concurrency at a time. Until it
+// arrives the lists show whatever is cached.
+func (m model) loadProjectInfo() tea.Cmd {
+	var stale []string
+	for _, path := range m.projectPaths {
+		if info, ok := m.projectInfo[path]; !ok || time.Since(info.checked) > projectInfoTTL {
+			stale = append(stale, path)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		infos := make(map[string]projectInfo, len(stale))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, max(cfg.Git.Concurrency, 1))
+		for _, path := range stale {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				info := detectProject(context.Background(), path)
+				mu.Lock()
+				infos[path] = info
+				mu.Unlock()
+				<-sem
+			}()
+		}
+		wg.Wait()
+		return projectInfoMsg{infos: infos}
+	}
+}
+
+func (m model) handleProjectInfo(msg projectInfoMsg) model {
+	if m.projectInfo == nil {
+		m.projectInfo = make(map[string]projectInfo)
+	}
+	for path, info := range msg.infos {
+		m.projectInfo[path] = info
+	}
+	return m
+}
+
+// renderProjectBadges renders the git state and stack badges of a project.
+func (m model) renderProjectBadges(path string) string {
+	info, ok := m.projectInfo[path]
+	if !ok {
+		return ""
+	}
+	var badges []string
+	if info.dirty {
+		badges = append(badges, subtitleStyle.Render("✚"))
+	}
+	for _, stack := range info.stacks {
+		for _, marker := range stackMarkers {
+			if marker.badge == stack {
+				badges = append(badges, lipgloss.NewStyle().Foreground(marker.color).Render(stack))
+			}
+		}
+	}
+	return strings.Join(badges, " ")
+}
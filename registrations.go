@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"commandy/portauthority"
+	"commandy/ports"
+)
+
+type registrationsMsg struct {
+	project string
+	regs    []portauthority.Registration
+	err     error
+
+	// Where the registrations were asked for, see handleRegistrations
+	state menuState
+	menu  *Menu
+}
+
+func paClient() *portauthority.Client {
+	return portauthority.New(cfg.PortAuthority.API)
+}
+
+// fetchProjectPorts loads the Port Authority registrations of a project.
+func (m model) fetchProjectPorts(project string) tea.Cmd {
+	state, menu := m.state, m.currentMenu()
+	return func() tea.Msg {
+		regs, err := paClient().ProjectPorts(context.Background(), project)
+		return registrationsMsg{project: project, regs: regs, err: err, state: state, menu: menu}
+	}
+}
+
+func registerPort(req portauthority.RegisterRequest) tea.Cmd {
+	return func() tea.Msg {
+		reg, err := paClient().Register(context.Background(), req)
+		if err != nil {
+			return statusMsg{err: err}
+		}
+		return statusMsg{text: fmt.Sprintf("Registered %s/%s on port %d", reg.Project, reg.Service, reg.Port)}
+	}
+}
+
+func updatePort(reg portauthority.Registration, port int) tea.Cmd {
+	return func() tea.Msg {
+		updated, err := paClient().Update(context.Background(), reg.ID, port)
+		if err != nil {
+			return statusMsg{err: err}
+		}
+		return statusMsg{text: fmt.Sprintf("Moved %s/%s from port %d to %d", reg.Project, reg.Service, reg.Port, updated.Port)}
+	}
+}
+
+func releasePort(reg portauthority.Registration) tea.Cmd {
+	return func() tea.Msg {
+		if err := paClient().Release(context.Background(), reg.ID); err != nil {
+			return statusMsg{err: err}
+		}
+		return statusMsg{text: fmt.Sprintf("Released port %d (%s/%s)", reg.Port, reg.Project, reg.Service)}
+	}
+}
+
+func formatRegistrations(regs []portauthority.Registration) string {
+	var lines []string
+	for _, r := range regs {
+		lines = append(lines, fmt.Sprintf("%-6d %-20s %s", r.Port, r.Service, r.Host))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// registrationLabel is how a registration is listed in stateSelectRegistration.
+func registrationLabel(r portauthority.Registration) string {
+	return fmt.Sprintf("%s :%d (%s)", r.Service, r.Port, r.Host)
+}
+
+// handleRegistrations shows the registrations loaded for the pending
+// Port Authority action. They are dropped when the user has moved on from
+// where they were asked for while they loaded.
+func (m model) handleRegistrations(msg registrationsMsg) (model, tea.Cmd) {
+	if m.state != msg.state || m.currentMenu() != msg.menu {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.message = fmt.Sprintf("Error: %v", msg.err)
+		m.messageType = "error"
+		return m, nil
+	}
+
+	m.paProject = msg.project
+	m.registrations = msg.regs
+	if len(msg.regs) == 0 {
+		m.message = fmt.Sprintf("No ports registered for %s", msg.project)
+		m.messageType = "info"
+		return m, nil
+	}
+
+	if m.pendingItem.Action == "port-authority-check" {
+		m.message = fmt.Sprintf("Ports registered for %s:\n%s", msg.project, formatRegistrations(msg.regs))
+		m.messageType = "success"
+		return m, nil
+	}
+
+	m.message = ""
+	m.messageType = ""
+	m.state = stateSelectRegistration
+	m.cursor = 0
+	return m, nil
+}
+
+func (m model) handleSelectRegistration() (model, tea.Cmd) {
+	if m.cursor >= len(m.registrations) {
+		return m.goBack(), nil
+	}
+	m.registration = m.registrations[m.cursor]
+
+	if m.pendingItem.Action == "port-authority-release" {
+		m = m.goBack()
+		m.message = fmt.Sprintf("Releasing port %d...", m.registration.Port)
+		m.messageType = "info"
+		return m, releasePort(m.registration)
+	}
+
+	prompt := fmt.Sprintf("New port for %s/%s (currently %d):", m.paProject, m.registration.Service, m.registration.Port)
+	return m.startInput(stateInputRegistrationPort, prompt, fmt.Sprint(m.registration.Port))
+}
+
+// submitRegistration handles "service" or "service=port" entered in
+// stateInputRegistration.
+func (m model) submitRegistration(value string) (model, tea.Cmd) {
+	service, portStr, hasPort := strings.Cut(strings.TrimSpace(value), "=")
+	service = strings.TrimSpace(service)
+	if service == "" {
+		m.message = "Service name cannot be empty"
+		m.messageType = "error"
+		return m, nil
+	}
+
+	req := portauthority.RegisterRequest{Project: m.paProject, Service: service, Host: hostname}
+	if hasPort {
+		port, err := ports.ParsePort(portStr)
+		if err != nil {
+			m.message = err.Error()
+			m.messageType = "error"
+			return m, nil
+		}
+		req.Port = port
+	}
+
+	m.textInput.Reset()
+	m.textInput.Blur()
+	m.state = stateMenu
+	m.message = fmt.Sprintf("Registering %s/%s...", m.paProject, service)
+	m.messageType = "info"
+	return m, registerPort(req)
+}
+
+// submitRegistrationPort handles the port entered in
+// stateInputRegistrationPort.
+func (m model) submitRegistrationPort(value string) (model, tea.Cmd) {
+	port, err := ports.ParsePort(value)
+	if err != nil {
+		m.message = err.Error()
+		m.messageType = "error"
+		return m, nil
+	}
+
+	m.textInput.Reset()
+	m.textInput.Blur()
+	m.state = stateMenu
+	m.message = fmt.Sprintf("Moving %s/%s to port %d...", m.paProject, m.registration.Service, port)
+	m.messageType = "info"
+	return m, updatePort(m.registration, port)
+}
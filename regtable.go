@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"commandy/portauthority"
+	"commandy/ports"
+)
+
+// Columns of the registrations table, in display order.
+const (
+	regColProject = iota
+	regColService
+	regColPort
+	regColHost
+	regColLastSeen
+	regColStatus
+)
+
+var regColumns = []table.Column{
+	{Title: "Project", Width: 20},
+	{Title: "Service", Width: 14},
+	{Title: "Port", Width: 6},
+	{Title: "Host", Width: 14},
+	{Title: "Last seen", Width: 10},
+	{Title: "Local", Width: 16},
+}
+
+type registrationTableMsg struct {
+	regs      []portauthority.Registration
+	listening map[int]bool // Ports listening on this machine
+	err       error
+}
+
+// fetchRegistrationTable loads every registration and scans the local ports
+// so registrations that aren't listening can be flagged.
+func fetchRegistrationTable() tea.Cmd {
+	return func() tea.Msg {
+		regs, err := paClient().List(context.Background())
+		if err != nil {
+			return registrationTableMsg{err: err}
+		}
+		listening := make(map[int]bool)
+		if listeners, err := ports.Scan(); err == nil {
+			for _, l := range listeners {
+				listening[l.Port] = true
+			}
+		}
+		return registrationTableMsg{regs: regs, listening: listening}
+	}
+}
+
+// registrationTable is the sortable, filterable view of every Port
+// Authority registration shown in stateRegistrationsTable.
+type registrationTable struct {
+	table     table.Model
+	regs      []portauthority.Registration
+	shown     []portauthority.Registration // regs after filtering and sorting, in table order
+	listening map[int]bool
+	filter    string
+	sortCol   int
+	sortDesc  bool
+}
+
+func newRegistrationTable(regs []portauthority.Registration, listening map[int]bool, height int) registrationTable {
+	// Only non-printable keys move the cursor; printable ones go to the filter
+	keys := table.KeyMap{
+		LineUp:       key.NewBinding(key.WithKeys("up")),
+		LineDown:     key.NewBinding(key.WithKeys("down")),
+		PageUp:       key.NewBinding(key.WithKeys("pgup")),
+		PageDown:     key.NewBinding(key.WithKeys("pgdown")),
+		HalfPageUp:   key.NewBinding(key.WithKeys("ctrl+u")),
+		HalfPageDown: key.NewBinding(key.WithKeys("ctrl+d")),
+		GotoTop:      key.NewBinding(key.WithKeys("home")),
+		GotoBottom:   key.NewBinding(key.WithKeys("end")),
+	}
+
+	styles := table.DefaultStyles()
+	styles.Header = styles.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(cyan).
+		BorderBottom(true).
+		Foreground(blue).
+		Bold(true)
+	styles.Selected = selectedStyle
+
+	t := registrationTable{
+		table: table.New(
+			table.WithKeyMap(keys),
+			table.WithStyles(styles),
+			table.WithFocused(true),
+			table.WithHeight(height),
+		),
+		regs:      regs,
+		listening: listening,
+		sortCol:   regColProject,
+	}
+	t.refresh()
+	return t
+}
+
+// refresh reapplies the filter and sort order to the table rows.
+func (t *registrationTable) refresh() {
+	filter := strings.ToLower(t.filter)
+	t.shown = t.shown[:0]
+	for _, r := range t.regs {
+		if filter == "" || strings.Contains(strings.ToLower(strings.Join(t.row(r), " ")), filter) {
+			t.shown = append(t.shown, r)
+		}
+	}
+
+	sort.SliceStable(t.shown, func(i, j int) bool {
+		a, b := t.shown[i], t.shown[j]
+		var less, greater bool
+		switch t.sortCol {
+		case regColPort:
+			less, greater = a.Port < b.Port, a.Port > b.Port
+		case regColLastSeen:
+			// Most recent first
+			less, greater = a.LastSeen.After(b.LastSeen), a.LastSeen.Before(b.LastSeen)
+		default:
+			x, y := strings.ToLower(t.row(a)[t.sortCol]), strings.ToLower(t.row(b)[t.sortCol])
+			less, greater = x < y, x > y
+		}
+		if t.sortDesc {
+			return greater
+		}
+		return less
+	})
+
+	cols := make([]table.Column, len(regColumns))
+	copy(cols, regColumns)
+	arrow := " ▲"
+	if t.sortDesc {
+		arrow = " ▼"
+	}
+	cols[t.sortCol].Title += arrow
+
+	rows := make([]table.Row, len(t.shown))
+	for i, r := range t.shown {
+		rows[i] = t.row(r)
+	}
+	t.table.SetColumns(cols)
+	t.table.SetRows(rows)
+	if t.table.Cursor() >= len(rows) {
+		t.table.SetCursor(max(len(rows)-1, 0))
+	}
+}
+
+// row returns the table cells for a registration.
+func (t registrationTable) row(r portauthority.Registration) table.Row {
+	status := "-"
+	if isLocalHost(r.Host) {
+		status = "● listening"
+		if !t.listening[r.Port] {
+			status = "○ not listening"
+		}
+	}
+	lastSeen := "never"
+	if !r.LastSeen.IsZero() {
+		lastSeen = timeAgo(r.LastSeen)
+	}
+	return table.Row{r.Project, r.Service, strconv.Itoa(r.Port), r.Host, lastSeen, status}
+}
+
+func (t registrationTable) Update(msg tea.KeyMsg) (registrationTable, tea.Cmd) {
+	switch msg.String() {
+	case "tab", "right":
+		t.sortCol = (t.sortCol + 1) % len(regColumns)
+		t.refresh()
+		return t, nil
+	case "shift+tab", "left":
+		t.sortCol = (t.sortCol + len(regColumns) - 1) % len(regColumns)
+		t.refresh()
+		return t, nil
+	case "ctrl+r":
+		t.sortDesc = !t.sortDesc
+		t.refresh()
+		return t, nil
+	case "backspace":
+		if r := []rune(t.filter); len(r) > 0 {
+			t.filter = string(r[:len(r)-1])
+			t.refresh()
+		}
+		return t, nil
+	}
+
+	if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+		t.filter += string(msg.Runes)
+		t.refresh()
+		return t, nil
+	}
+
+	var cmd tea.Cmd
+	t.table, cmd = t.table.Update(msg)
+	return t, cmd
+}
+
+func (t registrationTable) View() string {
+	var s strings.Builder
+
+	filter := dimStyle.Render("type to filter")
+	if t.filter != "" {
+		filter = normalStyle.Render(t.filter) + cursorStyle.Render("▏")
+	}
+	s.WriteString(subtitleStyle.Render("Filter: ") + filter + "\n\n")
+
+	s.WriteString(t.table.View())
+	s.WriteString("\n\n")
+
+	notListening := 0
+	for _, r := range t.shown {
+		if isLocalHost(r.Host) && !t.listening[r.Port] {
+			notListening++
+		}
+	}
+	summary := fmt.Sprintf("%d of %d registrations", len(t.shown), len(t.regs))
+	if notListening > 0 {
+		summary += " • " + errorStyle.Render(fmt.Sprintf("%d registered here but not listening", notListening))
+	}
+	s.WriteString(dimStyle.Render(summary))
+	return s.String()
+}
+
+// updateRegistrationTable handles keys in stateRegistrationsTable.
+func (m model) updateRegistrationTable(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		if m.regTable.filter != "" {
+			m.regTable.filter = ""
+			m.regTable.refresh()
+			return m, nil
+		}
+		return m.goBack(), nil
+	}
+
+	var cmd tea.Cmd
+	m.regTable, cmd = m.regTable.Update(msg)
+	return m, cmd
+}
+
+// regTableHeight is the number of table rows that fit under the banner.
+func (m model) regTableHeight() int {
+	used := lipgloss.Height(m.renderBanner()) + 10
+	return max(m.height-used, 5)
+}
+
+// isLocalHost reports whether a registration's host is this machine.
+func isLocalHost(host string) bool {
+	if host == "" || strings.EqualFold(host, "localhost") || strings.EqualFold(host, hostname) {
+		return true
+	}
+	if currentHost != nil {
+		if strings.EqualFold(host, currentHost.Name) {
+			return true
+		}
+		for _, hn := range currentHost.Hostnames {
+			if strings.EqualFold(host, hn) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// timeAgo formats the time since t, e.g. "5m ago" or "3d ago".
+func timeAgo(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	default:
+		return fmt.Sprintf("%dy ago", int(d.Hours()/24/365))
+	}
+}
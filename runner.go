@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// task is work whose output is shown in the pager. It writes its output to w
+// as it goes; the runner streams every line to the screen. ctx is cancelled
+// when the user cancels the task.
+type task func(ctx context.Context, w io.Writer) error
+
+// outputLine is a line of streamed output. cr marks a line ended by a bare
+// carriage return, such as a progress bar, which the next line replaces.
+type outputLine struct {
+	text string
+	cr   bool
+}
+
+// runEvent is sent from a running task to the UI.
+type runEvent struct {
+	lines []outputLine
+	done  bool
+	err   error
+}
+
+// commandTask runs a command, streaming its stdout and stderr.
+func commandTask(dir, name string, args ...string) task {
+	return func(ctx context.Context, w io.Writer) error {
+		cmd := groupCommand(ctx, name, args...)
+		cmd.Dir = dir
+		cmd.Env = colorEnv()
+		cmd.Stdout = w
+		cmd.Stderr = w
+		return cmd.Run()
+	}
+}
+
+// groupCommand returns a command that runs in its own process group, so that
+// cancelling ctx stops everything it started: the group gets SIGTERM, then
+// SIGKILL after killTimeout.
+func groupCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		pgid := -cmd.Process.Pid
+		time.AfterFunc(killTimeout, func() { syscall.Kill(pgid, syscall.SIGKILL) })
+		return syscall.Kill(pgid, syscall.SIGTERM)
+	}
+	// Stop waiting for output from children that ignored both signals
+	cmd.WaitDelay = killTimeout + time.Second
+	return cmd
+}
+
+// step is one part of a task that works through a list, such as pulling
+// one repository.
+type step struct {
+	name string
+	run  func(ctx context.Context, w io.Writer) error
+}
+
+// runSteps runs steps in order until ctx is cancelled. Steps report their own
+// results; when cancelled, runSteps lists which steps completed, which were
+// aborted and which never started.
+func runSteps(ctx context.Context, w io.Writer, steps []step) error {
+	return runStepsParallel(ctx, w, 1, steps)
+}
+
+// runStepsParallel is runSteps with up to n steps running at once. Steps
+// start in order but may finish in any order, so each should write its
+// results in a single Write.
+func runStepsParallel(ctx context.Context, w io.Writer, n int, steps []step) error {
+	const (
+		notStarted = iota
+		completed
+		aborted
+	)
+	state := make([]int, len(steps))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(n, 1), len(steps)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := steps[i].run(ctx, w); err != nil && ctx.Err() != nil {
+					state[i] = aborted
+				} else {
+					state[i] = completed
+				}
+			}
+		}()
+	}
+feed:
+	for i := range steps {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if ctx.Err() == nil {
+		return nil
+	}
+	fmt.Fprintln(w, "\n━━━ Cancelled ━━━")
+	for _, group := range []struct {
+		label string
+		state int
+	}{
+		{"Completed", completed},
+		{"Aborted", aborted},
+		{"Not started", notStarted},
+	} {
+		var names []string
+		for i, s := range steps {
+			if state[i] == group.state {
+				names = append(names, s.name)
+			}
+		}
+		if len(names) > 0 {
+			fmt.Fprintf(w, "%s (%d): %s\n", group.label, len(names), strings.Join(names, ", "))
+		}
+	}
+	return ctx.Err()
+}
+
+// lineWriter splits what is written to it into lines and sends them to the UI.
+type lineWriter struct {
+	mu     sync.Mutex
+	buf    []byte
+	events chan<- runEvent
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	var lines []outputLine
+	for {
+		i := bytes.IndexAny(w.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		if w.buf[i] == '\r' {
+			if i+1 == len(w.buf) {
+				// Wait for the next write to tell "\r" from "\r\n"
+				break
+			}
+			if w.buf[i+1] == '\n' {
+				lines = append(lines, outputLine{text: string(w.buf[:i])})
+				w.buf = w.buf[i+2:]
+				continue
+			}
+		}
+		lines = append(lines, outputLine{text: string(w.buf[:i]), cr: w.buf[i] == '\r'})
+		w.buf = w.buf[i+1:]
+	}
+	if len(lines) > 0 {
+		w.events <- runEvent{lines: lines}
+	}
+	return len(p), nil
+}
+
+// flush sends a final line that wasn't terminated by a newline.
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	text := string(bytes.TrimRight(w.buf, "\r"))
+	w.buf = nil
+	if text != "" {
+		w.events <- runEvent{lines: []outputLine{{text: text}}}
+	}
+}
+
+// exitCode returns the exit status of a finished command, or -1 when err is
+// not an exit status.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func formatElapsed(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
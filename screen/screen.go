@@ -0,0 +1,113 @@
+// Package screen controls GNU screen sessions by running the screen command
+// line.
+package screen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Session is a running screen session.
+type Session struct {
+	ID       string // "<pid>.<name>", which names the session unambiguously
+	Name     string
+	Attached bool
+}
+
+// Available reports whether the screen command is installed.
+func Available() bool {
+	_, err := exec.LookPath("screen")
+	return err == nil
+}
+
+// Inside reports whether this process runs inside a screen session.
+func Inside() bool {
+	return os.Getenv("STY") != ""
+}
+
+// Sessions lists the running sessions. Dead sessions, whose process is
+// gone, are left out.
+func Sessions(ctx context.Context) ([]Session, error) {
+	// screen -ls exits non-zero even when it lists sessions, so its output
+	// decides
+	out, _ := exec.CommandContext(ctx, "screen", "-ls").Output()
+	var sessions []Session
+	for _, line := range strings.Split(string(out), "\n") {
+		// \t12345.name\t(10/16/26 14:33:21)\t(Attached)
+		if !strings.HasPrefix(line, "\t") {
+			continue
+		}
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		state := fields[len(fields)-1]
+		_, name, ok := strings.Cut(fields[0], ".")
+		if !ok || strings.Contains(state, "Dead") {
+			continue
+		}
+		sessions = append(sessions, Session{
+			ID:       fields[0],
+			Name:     name,
+			Attached: strings.Contains(strings.ToLower(state), "attached"), // Also "(Multi, attached)"
+		})
+	}
+	return sessions, nil
+}
+
+// NewSession starts the detached session name in dir, running command
+// instead of a shell when one is given.
+func NewSession(ctx context.Context, name, dir string, command ...string) error {
+	args := []string{"-dmS", name}
+	cmd := exec.CommandContext(ctx, "screen", append(args, command...)...)
+	cmd.Dir = dir
+	return runCmd(cmd, "-dmS")
+}
+
+// NewWindow adds a window in dir to the session id, running command instead
+// of a shell when one is given.
+func NewWindow(ctx context.Context, id, dir string, command ...string) error {
+	if err := send(ctx, id, "chdir", dir); err != nil {
+		return err
+	}
+	return send(ctx, id, append([]string{"screen"}, command...)...)
+}
+
+// RenameSession renames the session id to newName.
+func RenameSession(ctx context.Context, id, newName string) error {
+	return send(ctx, id, "sessionname", newName)
+}
+
+// KillSession ends the session id and everything running in it.
+func KillSession(ctx context.Context, id string) error {
+	return send(ctx, id, "quit")
+}
+
+// AttachCommand returns the command that attaches the terminal to the
+// session id, alongside any terminal already attached. It takes over the
+// terminal, so it is run by the caller.
+func AttachCommand(id string) *exec.Cmd {
+	return exec.Command("screen", "-x", id)
+}
+
+// send sends a screen command to the session id.
+func send(ctx context.Context, id string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "screen", append([]string{"-S", id, "-X"}, args...)...)
+	return runCmd(cmd, args[0])
+}
+
+func runCmd(cmd *exec.Cmd, name string) error {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(output.String()); msg != "" && errors.As(err, &exitErr) {
+			return fmt.Errorf("screen %s: %s", name, msg)
+		}
+		return fmt.Errorf("screen %s: %w", name, err)
+	}
+	return nil
+}
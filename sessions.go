@@ -0,0 +1,571 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"commandy/tmux"
+)
+
+// sessionInfo is a session with what the session browser shows of it.
+// Windows, panes and attached terminals are only known for tmux.
+type sessionInfo struct {
+	muxSession
+	windows     []tmux.Window
+	panes       []tmux.Pane
+	attachments []tmux.Attachment
+	project     bool // Named after a known project, see sanitizeSessionName
+	current     bool // The session commandy runs in, never killed in bulk
+}
+
+// sessionTarget is a window or pane offered on a session's screen.
+type sessionTarget struct {
+	label string
+	id    string // Window or pane ID, e.g. "@3" or "%7"
+}
+
+// loadSessions reads the sessions of m.mux, most recently active first,
+// and for tmux their windows, panes and attached terminals. Marks of
+// sessions that are gone are dropped.
+func (m *model) loadSessions() {
+	m.sessions = nil
+	m.sessionTTY = ""
+	ctx := context.Background()
+	sessions, err := m.mux.Sessions(ctx)
+	var windows []tmux.Window
+	var panes []tmux.Pane
+	var attachments []tmux.Attachment
+	if t, ok := m.mux.(tmuxMux); ok && err == nil {
+		client := t.client
+		if client.Inside() {
+			m.sessionTTY, _ = client.ClientTTY(ctx)
+		}
+		windows, err = client.Windows(ctx, "")
+		if err == nil {
+			panes, err = client.Panes(ctx, "")
+		}
+		if err == nil {
+			attachments, err = client.Attachments(ctx, "")
+		}
+	}
+	if err != nil {
+		m.message = fmt.Sprintf("Error: %v", err)
+		m.messageType = "error"
+		return
+	}
+
+	known := make(map[string]bool)
+	names, _ := discoverProjects(nil)
+	for _, name := range names {
+		known[sanitizeSessionName(name)] = true
+	}
+	index := make(map[string]int, len(sessions))
+	for i, s := range sessions {
+		index[s.Name] = i
+		m.sessions = append(m.sessions, sessionInfo{muxSession: s, project: known[s.Name], current: s.Current})
+	}
+	for name := range m.sessionMarks {
+		if _, ok := index[name]; !ok {
+			delete(m.sessionMarks, name)
+		}
+	}
+	// Sessions started or ended between the commands are left out
+	for _, w := range windows {
+		if i, ok := index[w.Session]; ok {
+			m.sessions[i].windows = append(m.sessions[i].windows, w)
+		}
+	}
+	for _, p := range panes {
+		if i, ok := index[p.Session]; ok {
+			m.sessions[i].panes = append(m.sessions[i].panes, p)
+		}
+	}
+	for _, a := range attachments {
+		if i, ok := index[a.Session]; ok {
+			m.sessions[i].attachments = append(m.sessions[i].attachments, a)
+			if m.sessionTTY != "" && a.TTY == m.sessionTTY {
+				m.sessions[i].current = true
+			}
+		}
+	}
+	slices.SortStableFunc(m.sessions, func(a, b sessionInfo) int {
+		return b.Activity.Compare(a.Activity)
+	})
+}
+
+// selectedSessionInfo returns the session shown in stateSessionActions.
+func (m model) selectedSessionInfo() (sessionInfo, bool) {
+	for _, s := range m.sessions {
+		if s.Name == m.selectedSession {
+			return s, true
+		}
+	}
+	return sessionInfo{}, false
+}
+
+// sessionItems lists the sessions with their summaries, e.g.
+// "api   3 windows • attached • active 5m ago". While sessions are marked,
+// each is shown with a checkbox.
+func (m model) sessionItems() []string {
+	width := 0
+	for _, s := range m.sessions {
+		width = max(width, len([]rune(s.Name)))
+	}
+	var items []string
+	for _, s := range m.sessions {
+		item := fmt.Sprintf("%-*s  %s", width, s.Name, sessionSummary(s))
+		if len(m.sessionMarks) > 0 {
+			box := "[ ] "
+			if m.sessionMarks[s.Name] {
+				box = "[x] "
+			}
+			item = box + item
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// sessionSummary describes the size, clients and activity of a session, as
+// far as the multiplexer reports them, and whether a project goes with it.
+func sessionSummary(s sessionInfo) string {
+	var parts []string
+	if s.Windows > 0 {
+		parts = append(parts, countLabel(s.Windows, "window"))
+	}
+	switch {
+	case s.Attached == 0:
+		parts = append(parts, "detached")
+	case s.Attached == 1:
+		parts = append(parts, "attached")
+	default:
+		parts = append(parts, fmt.Sprintf("%d clients", s.Attached))
+	}
+	if s.current {
+		parts = append(parts, "current")
+	}
+	switch {
+	case !s.Activity.IsZero():
+		parts = append(parts, "active "+timeAgo(s.Activity))
+	case !s.Created.IsZero():
+		parts = append(parts, "created "+timeAgo(s.Created))
+	}
+	if !s.project {
+		parts = append(parts, "no project")
+	}
+	return strings.Join(parts, " • ")
+}
+
+// sessionListActions are the items below the session list.
+func (m model) sessionListActions() []string {
+	var actions []string
+	if n := len(m.markedSessions()); n > 0 {
+		actions = append(actions, fmt.Sprintf("Kill marked sessions (%d)", n))
+	}
+	if n := len(m.orphanSessions()); n > 0 {
+		actions = append(actions, fmt.Sprintf("Kill sessions without a project (%d)", n))
+	}
+	// Only tmux reports activity to tell idle sessions by
+	if slices.ContainsFunc(m.sessions, func(s sessionInfo) bool { return !s.Activity.IsZero() }) {
+		actions = append(actions, "Kill idle sessions")
+	}
+	return append(actions, "Back")
+}
+
+// markedSessions returns the names of the marked sessions, in list order,
+// except the one commandy runs in.
+func (m model) markedSessions() []string {
+	var names []string
+	for _, s := range m.sessions {
+		if m.sessionMarks[s.Name] && !s.current {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// orphanSessions returns the names of the sessions not named after a known
+// project, such as those of deleted projects or started by hand, except the
+// one commandy runs in.
+func (m model) orphanSessions() []string {
+	var names []string
+	for _, s := range m.sessions {
+		if !s.project && !s.current {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// otherClients returns the terminals attached to the selected session
+// besides the one commandy runs in.
+func (m model) otherClients() []tmux.Attachment {
+	s, _ := m.selectedSessionInfo()
+	var others []tmux.Attachment
+	for _, a := range s.attachments {
+		if a.TTY != m.sessionTTY {
+			others = append(others, a)
+		}
+	}
+	return others
+}
+
+// updateSessionMarks marks sessions for a bulk action: space toggles the
+// session under the cursor and moves on, a marks every session or, when
+// all are marked, none. It reports whether the key was used.
+func (m model) updateSessionMarks(msg tea.KeyMsg) (model, bool) {
+	switch msg.String() {
+	case " ":
+		if m.cursor >= len(m.sessions) {
+			return m, false
+		}
+		name := m.sessions[m.cursor].Name
+		marks := maps.Clone(m.sessionMarks)
+		if marks == nil {
+			marks = make(map[string]bool)
+		}
+		if marks[name] {
+			delete(marks, name)
+		} else {
+			marks[name] = true
+		}
+		m.sessionMarks = marks
+		if m.cursor < len(m.sessions)-1 {
+			m.cursor++
+		}
+	case "a":
+		if len(m.sessions) == 0 {
+			return m, false
+		}
+		all := len(m.sessionMarks) == len(m.sessions)
+		m.sessionMarks = nil
+		if !all {
+			m.sessionMarks = make(map[string]bool)
+			for _, s := range m.sessions {
+				m.sessionMarks[s.Name] = true
+			}
+		}
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// sessionTargets lists the windows of the selected session, each followed by
+// its panes with the command running in them.
+func (m model) sessionTargets() []sessionTarget {
+	s, ok := m.selectedSessionInfo()
+	if !ok {
+		return nil
+	}
+	var targets []sessionTarget
+	for _, w := range s.windows {
+		label := fmt.Sprintf("Window %d: %s", w.Index, w.Name)
+		if w.Active {
+			label += " (current)"
+		}
+		targets = append(targets, sessionTarget{label: label, id: w.ID})
+
+		var panes []tmux.Pane
+		for _, p := range s.panes {
+			if p.Window == w.Index {
+				panes = append(panes, p)
+			}
+		}
+		for i, p := range panes {
+			connector := "├"
+			if i == len(panes)-1 {
+				connector = "└"
+			}
+			label := fmt.Sprintf("  %s pane %d.%d  %s  %s", connector, w.Index, p.Index, p.Command, shortenHome(p.Path))
+			if p.Active && len(panes) > 1 {
+				label += " (active)"
+			}
+			targets = append(targets, sessionTarget{label: label, id: p.ID})
+		}
+	}
+	return targets
+}
+
+// renderSession renders the details above a session's windows and panes.
+func (m model) renderSession() string {
+	s, ok := m.selectedSessionInfo()
+	if !ok {
+		return ""
+	}
+	var b strings.Builder
+	label := func(l string) string { return subtitleStyle.Render(fmt.Sprintf("  %-10s", l)) }
+
+	if !s.Created.IsZero() {
+		b.WriteString(label("Created") + normalStyle.Render(s.Created.Format("2006-01-02 15:04")) +
+			dimStyle.Render(" ("+timeAgo(s.Created)+")") + "\n")
+	}
+	if !s.Activity.IsZero() {
+		b.WriteString(label("Activity") + normalStyle.Render(timeAgo(s.Activity)) + "\n")
+	}
+	if s.Path != "" {
+		b.WriteString(label("Directory") + normalStyle.Render(shortenHome(s.Path)) + "\n")
+	}
+	if _, ok := m.mux.(tmuxMux); !ok {
+		// Other multiplexers only tell whether someone is attached
+		if s.Attached == 0 {
+			b.WriteString(label("Clients") + dimStyle.Render("none, detached") + "\n")
+		} else {
+			b.WriteString(label("Clients") + normalStyle.Render("attached") + "\n")
+		}
+	} else if len(s.attachments) == 0 {
+		b.WriteString(label("Clients") + dimStyle.Render("none, detached") + "\n")
+	}
+	for i, a := range s.attachments {
+		l := ""
+		if i == 0 {
+			l = "Clients"
+		}
+		b.WriteString(label(l) + normalStyle.Render(a.TTY) +
+			dimStyle.Render(fmt.Sprintf(" %s %dx%d, active %s", a.Term, a.Width, a.Height, timeAgo(a.Activity))) + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (m model) handleSessions(selected string) (model, tea.Cmd) {
+	if m.cursor < len(m.sessions) {
+		m.selectedSession = m.sessions[m.cursor].Name
+		m.state = stateSessionActions
+		m.cursor = 0
+		return m, nil
+	}
+
+	switch {
+	case strings.HasPrefix(selected, "Kill marked sessions"):
+		m.selectedSession = ""
+		return m.confirmKillSessions(m.markedSessions()), nil
+	case strings.HasPrefix(selected, "Kill sessions without a project"):
+		m.selectedSession = ""
+		return m.confirmKillSessions(m.orphanSessions()), nil
+	case selected == "Kill idle sessions":
+		return m.startInput(stateInputIdleHours, "Kill detached sessions with no activity in the last how many hours?", "24")
+	case selected == "Back":
+		return m.goBack(), nil
+	}
+	return m, nil
+}
+
+func (m model) handleSessionActions(selected string) (model, tea.Cmd) {
+	switch selected {
+	case "Resume":
+		return m, resumeSession(m.mux, m.selectedSession)
+	case "Rename session":
+		m, cmd := m.startInput(stateInputSessionName, "New name for the session:", m.selectedSession)
+		m.textInput.SetValue(m.selectedSession)
+		return m, cmd
+	case "Detach other clients":
+		return m.detachOtherClients(), nil
+	case "Kill session":
+		return m.confirmKillSessions([]string{m.selectedSession}), nil
+	case "Back":
+		return m.goBack(), nil
+	}
+
+	// A window or pane
+	if targets := m.sessionTargets(); m.cursor >= 1 && m.cursor <= len(targets) {
+		return m, jumpToTarget(m.mux, m.selectedSession, targets[m.cursor-1].id)
+	}
+	return m, nil
+}
+
+// jumpToTarget makes target, a tmux window or pane ID, current in session
+// and takes the user there.
+func jumpToTarget(mux multiplexer, session, target string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		if t, ok := mux.(tmuxMux); ok {
+			if err := t.client.Select(ctx, target); err != nil {
+				return statusMsg{err: err}
+			}
+		}
+		return switchOrAttach(ctx, mux, session)
+	}
+}
+
+// countLabel formats n with noun, made plural unless n is 1.
+func countLabel(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// shortenHome abbreviates the home directory in path as ~.
+func shortenHome(path string) string {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, home+"/"); ok {
+		return "~/" + rest
+	}
+	return path
+}
+
+// submitSessionName renames the selected session to what was entered in
+// stateInputSessionName.
+func (m model) submitSessionName(value string) (model, tea.Cmd) {
+	name := strings.TrimSpace(value)
+	switch {
+	case name == "":
+		m.inputError = "session name cannot be empty"
+		return m, nil
+	case strings.ContainsAny(name, ".:"):
+		// tmux uses them in targets such as "session:window.pane", and
+		// sessions are named after projects the same way everywhere
+		m.inputError = "session name cannot contain '.' or ':'"
+		return m, nil
+	}
+	if name != m.selectedSession {
+		renamer, ok := m.mux.(sessionRenamer)
+		if !ok {
+			m.inputError = m.mux.Name() + " can't rename sessions"
+			return m, nil
+		}
+		if err := renamer.RenameSession(context.Background(), m.selectedSession, name); err != nil {
+			m.inputError = err.Error()
+			return m, nil
+		}
+		m.message = fmt.Sprintf("Renamed session '%s' to '%s'", m.selectedSession, name)
+		m.messageType = "success"
+		m.selectedSession = name
+	}
+	m.textInput.Reset()
+	m.textInput.Blur()
+	m.state = stateSessionActions
+	m.cursor = 0
+	m.loadSessions()
+	return m, nil
+}
+
+// submitIdleHours asks to kill the detached sessions without activity in
+// the number of hours entered in stateInputIdleHours.
+func (m model) submitIdleHours(value string) (model, tea.Cmd) {
+	hours, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || hours < 1 {
+		m.inputError = "enter a whole number of hours, at least 1"
+		return m, nil
+	}
+	var idle []string
+	for _, s := range m.sessions {
+		if s.Attached == 0 && !s.current && time.Since(s.Activity) >= time.Duration(hours)*time.Hour {
+			idle = append(idle, s.Name)
+		}
+	}
+	m.textInput.Reset()
+	m.textInput.Blur()
+	if len(idle) == 0 {
+		m.state = stateSessions
+		m.cursor = 0
+		m.message = fmt.Sprintf("No detached session has been idle for %s", countLabel(hours, "hour"))
+		m.messageType = "info"
+		return m, nil
+	}
+	m.selectedSession = ""
+	return m.confirmKillSessions(idle), nil
+}
+
+// detachOtherClients detaches every terminal attached to the selected
+// session except the one commandy runs in.
+func (m model) detachOtherClients() model {
+	ctx := context.Background()
+	client := m.mux.(tmuxMux).client
+	detached := 0
+	var problems []string
+	for _, a := range m.otherClients() {
+		if err := client.DetachClient(ctx, a.TTY); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		detached++
+	}
+	m.loadSessions()
+	if len(problems) > 0 {
+		m.message = fmt.Sprintf("Detached %s, but:\n%s", countLabel(detached, "client"), strings.Join(problems, "\n"))
+		m.messageType = "error"
+		return m
+	}
+	m.message = fmt.Sprintf("Detached %s from '%s'", countLabel(detached, "client"), m.selectedSession)
+	m.messageType = "success"
+	return m
+}
+
+// confirmKillSessions asks before killing the sessions names.
+func (m model) confirmKillSessions(names []string) model {
+	m.killSessions = names
+	m.state = stateConfirmKillSessions
+	m.cursor = 0
+	return m
+}
+
+// renderKillSessions lists the sessions waiting for confirmation in
+// stateConfirmKillSessions.
+func (m model) renderKillSessions() string {
+	width := 0
+	for _, name := range m.killSessions {
+		width = max(width, len([]rune(name)))
+	}
+	var b strings.Builder
+	for _, name := range m.killSessions {
+		line := "  " + selectedStyle.Render(fmt.Sprintf("%-*s", width, name))
+		for _, s := range m.sessions {
+			if s.Name == name {
+				line += dimStyle.Render("  " + sessionSummary(s))
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (m model) handleConfirmKillSessions(selected string) (model, tea.Cmd) {
+	if selected == "Cancel" {
+		return m.goBack(), nil
+	}
+
+	ctx := context.Background()
+	names := m.killSessions
+	var problems []string
+	for _, name := range names {
+		if err := m.mux.KillSession(ctx, name); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	m.killSessions = nil
+	m.selectedSession = ""
+	m.state = stateSessions
+	m.cursor = 0
+	m.loadSessions()
+
+	killed := len(names) - len(problems)
+	switch {
+	case len(problems) > 0:
+		m.message = fmt.Sprintf("Killed %s, but:\n%s", countLabel(killed, "session"), strings.Join(problems, "\n"))
+		m.messageType = "error"
+	case killed == 1:
+		m.message = fmt.Sprintf("Killed %s session '%s'", m.mux.Name(), names[0])
+		m.messageType = "success"
+	default:
+		m.message = fmt.Sprintf("Killed %s", countLabel(killed, m.mux.Name()+" session"))
+		m.messageType = "success"
+	}
+	return m, nil
+}
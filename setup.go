@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxProjectName is the longest project name accepted by "Setup New Project".
+const maxProjectName = 64
+
+var projectNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// validateProjectName checks a name for a new project in projectsDir. Names
+// are a single directory and make a usable tmux session name.
+func validateProjectName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("project name cannot be empty")
+	case strings.ContainsAny(name, `/\`):
+		return errors.New("project name cannot contain slashes")
+	case strings.ContainsFunc(name, unicode.IsSpace):
+		return errors.New("project name cannot contain spaces")
+	case len(name) > maxProjectName:
+		return fmt.Errorf("project name cannot be longer than %d characters", maxProjectName)
+	case !unicode.IsLetter(rune(name[0])) && !unicode.IsDigit(rune(name[0])):
+		return errors.New("project name must start with a letter or digit")
+	case !projectNamePattern.MatchString(name):
+		return errors.New("project name can only contain letters, digits, '.', '_' and '-'")
+	}
+	return nil
+}
+
+// isCloneURL reports whether what was typed in stateSetupProject, or a
+// template source, is a git URL to clone: one with a scheme, or
+// "git@host:path". A name or path ending in ".git" is still local.
+func isCloneURL(value string) bool {
+	if strings.Contains(value, "://") {
+		return true
+	}
+	rest, ok := strings.CutPrefix(value, "git@")
+	if !ok {
+		return false
+	}
+	host, path, ok := strings.Cut(rest, ":")
+	return ok && host != "" && path != ""
+}
+
+// cloneName returns the project name for a clone of url: the repository
+// name without ".git", as git itself would name it.
+func cloneName(url string) string {
+	url = strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+		url = url[i+1:]
+	}
+	return url
+}
+
+// checkNewProject checks what was typed in stateSetupProject: a project name,
+// or a git URL to clone. It returns the name and path of the project that
+// would be created.
+func checkNewProject(value string) (name, path string, err error) {
+	name = value
+	if isCloneURL(value) {
+		name = cloneName(value)
+	}
+	if err := validateProjectName(name); err != nil {
+		if isCloneURL(value) {
+			return name, "", fmt.Errorf("can't clone into %q: %w", name, err)
+		}
+		return name, "", err
+	}
+	path = filepath.Join(projectsDir, name)
+	if _, err := os.Stat(path); err == nil {
+		return name, path, fmt.Errorf("%s already exists", path)
+	}
+	return name, path, nil
+}
+
+// updateSetupInputError checks the setup input as it is typed, so mistakes
+// show under it before enter is pressed.
+func (m model) updateSetupInputError() model {
+	m.inputError = ""
+	if value := strings.TrimSpace(m.textInput.Value()); value != "" {
+		if _, _, err := checkNewProject(value); err != nil {
+			m.inputError = err.Error()
+		}
+	}
+	return m
+}
+
+// submitSetup creates a project from what was entered in stateSetupProject:
+// a git URL is cloned, a name goes on to the templates.
+func (m model) submitSetup(value string) (model, tea.Cmd) {
+	value = strings.TrimSpace(value)
+	name, path, err := checkNewProject(value)
+	if err != nil {
+		m.inputError = err.Error()
+		return m, nil
+	}
+	if !isCloneURL(value) {
+		return m.openTemplates(name), nil
+	}
+
+	m.outputTitle = fmt.Sprintf("Clone %s", name)
+	m.runAfter = afterCreate(name, path, "cloned from "+value)
+	m, cmd := m.startRun(cloneProject(value, path))
+	m.runAfter = nil
+	return m, cmd
+}
+
+// cloneProject clones url into path, streaming git's progress.
+func cloneProject(url, path string) task {
+	return func(ctx context.Context, w io.Writer) error {
+		fmt.Fprintf(w, "Cloning %s into %s...\n", url, path)
+		cmd := groupCommand(ctx, "git", "clone", "--progress", url, path)
+		// Fail instead of prompting for credentials on the terminal the TUI owns
+		cmd.Env = append(colorEnv(), "GIT_TERMINAL_PROMPT=0")
+		cmd.Stdout = w
+		cmd.Stderr = w
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\n✓ Cloned %s\n", filepath.Base(path))
+		return nil
+	}
+}
+
+// afterCreate returns the runAfter of a job creating the project name at
+// path. Whatever failed, a project that got created is worth opening, so it
+// continues to stateSetupProjectConfirm; how describes where the project
+// came from in the message.
+func afterCreate(name, path, how string) func(m model, j *job, err error) model {
+	return func(m model, j *job, err error) model {
+		if _, statErr := os.Stat(path); statErr != nil {
+			return m
+		}
+		m.selectedProject = name
+		m.selectedPath = path
+		m.textInput.Reset()
+		m.inputError = ""
+		j.pager.returnTo = stateSetupProjectConfirm
+		switch {
+		case m.state == stateSetupProject || m.state == stateSelectTemplate:
+			m.state = stateSetupProjectConfirm
+			m.cursor = 0
+		case m.job == j:
+			// Watching the output, which returns to the confirmation
+			m.cursor = 0
+		}
+		if err != nil {
+			m.message = fmt.Sprintf("Project '%s' created at %s, but: %v", name, path, err)
+			m.messageType = "error"
+		} else {
+			m.message = fmt.Sprintf("Project '%s' created at %s, %s", name, path, how)
+			m.messageType = "success"
+		}
+		return m
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+)
+
+// templateManifest is the file that marks a directory or git repository as
+// a project template.
+const templateManifest = "commandy-template.yaml"
+
+// projectTemplate is a template offered by "Setup New Project", described
+// by its commandy-template.yaml:
+//
+//	name: Go service
+//	description: HTTP service with a Makefile
+//	variables:
+//	  module: github.com/me/{{name}}
+//	hooks:
+//	  - go mod init {{module}}
+//	commit: Start {{name}}
+//
+// {{name}}, {{author}}, {{year}} and the template's own variables are
+// replaced in file contents, file names and hooks.
+type projectTemplate struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Variables   map[string]string `yaml:"variables"`
+	Hooks       []string          `yaml:"hooks"`  // Shell commands run in the new project
+	Commit      string            `yaml:"commit"` // Initial commit message
+
+	source string // Directory or git URL
+	remote bool   // source is a git repository, cloned on use
+}
+
+// loadTemplates returns the templates from cfg.Templates.Sources. A local
+// source is either a template itself or a directory of templates; a git
+// source, see isCloneURL, is listed by name and only read when it is used.
+// Templates that can't be read are reported in problems and left out.
+func loadTemplates() (templates []projectTemplate, problems []string) {
+	for _, source := range cfg.Templates.Sources {
+		if isCloneURL(source) {
+			templates = append(templates, projectTemplate{Name: cloneName(source), source: source, remote: true})
+			continue
+		}
+
+		dir := expandPath(source)
+		if _, err := os.Stat(filepath.Join(dir, templateManifest)); err == nil {
+			t, err := readTemplate(dir, filepath.Base(dir))
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			templates = append(templates, t)
+			continue
+		}
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			sub := filepath.Join(dir, entry.Name())
+			if _, err := os.Stat(filepath.Join(sub, templateManifest)); !entry.IsDir() || err != nil {
+				continue
+			}
+			t, err := readTemplate(sub, entry.Name())
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			templates = append(templates, t)
+		}
+	}
+	return templates, problems
+}
+
+// readTemplate reads the manifest of the template in dir. name is used when
+// the manifest doesn't set one.
+func readTemplate(dir, name string) (projectTemplate, error) {
+	t := projectTemplate{source: dir}
+	data, err := os.ReadFile(filepath.Join(dir, templateManifest))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return t, err
+	}
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return t, fmt.Errorf("%s: %w", filepath.Join(dir, templateManifest), err)
+	}
+	if t.Name == "" {
+		t.Name = name
+	}
+	return t, nil
+}
+
+// label is how the template is listed.
+func (t projectTemplate) label() string {
+	switch {
+	case t.remote:
+		return t.Name + " (git)"
+	case t.Description != "":
+		return t.Name + " — " + t.Description
+	}
+	return t.Name
+}
+
+// templateVars returns the replacements for the placeholders of t in a
+// project called name. Template variables may use the built-in ones.
+func templateVars(t projectTemplate, name string) *strings.Replacer {
+	author := os.Getenv("USER")
+	if out, err := exec.Command("git", "config", "user.name").Output(); err == nil && len(bytes.TrimSpace(out)) > 0 {
+		author = string(bytes.TrimSpace(out))
+	}
+	builtin := []string{
+		"{{name}}", name,
+		"{{author}}", author,
+		"{{year}}", strconv.Itoa(time.Now().Year()),
+	}
+	r := strings.NewReplacer(builtin...)
+	pairs := builtin
+	for key, value := range t.Variables {
+		pairs = append(pairs, "{{"+key+"}}", r.Replace(value))
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// copyTemplate copies the template in src to dst, replacing placeholders in
+// names and in the contents of text files. The manifest and the template's
+// .git directory are left behind.
+func copyTemplate(src, dst string, vars *strings.Replacer) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, p)
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if rel == templateManifest {
+			return nil
+		}
+		target := filepath.Join(dst, vars.Replace(rel))
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		// Leave binary files as they are
+		if !bytes.Contains(data, []byte{0}) {
+			data = []byte(vars.Replace(string(data)))
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
+
+// createFromTemplate creates the project name at path from t: it copies the
+// template (cloning it first if it is a git repository), runs its hooks and
+// makes the initial commit.
+func createFromTemplate(t projectTemplate, name, path string) task {
+	return func(ctx context.Context, w io.Writer) error {
+		src := t.source
+		if t.remote {
+			tmp, err := os.MkdirTemp("", "commandy-template-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(tmp)
+			fmt.Fprintf(w, "Cloning %s...\n", t.source)
+			clone := groupCommand(ctx, "git", "clone", "--depth", "1", "--progress", t.source, tmp)
+			clone.Stdout, clone.Stderr = w, w
+			if err := clone.Run(); err != nil {
+				return fmt.Errorf("cloning %s: %w", t.source, err)
+			}
+			if t, err = readTemplate(tmp, t.Name); err != nil {
+				return err
+			}
+			src = tmp
+		}
+
+		vars := templateVars(t, name)
+		fmt.Fprintf(w, "Copying template %s to %s...\n", t.Name, path)
+		if err := copyTemplate(src, path, vars); err != nil {
+			os.RemoveAll(path)
+			return fmt.Errorf("copying template: %w", err)
+		}
+
+		fmt.Fprintln(w, "Initializing git...")
+		if err := runInDir(ctx, w, path, "git", "init", "--quiet"); err != nil {
+			return fmt.Errorf("git init: %w", err)
+		}
+		for _, hook := range t.Hooks {
+			hook = vars.Replace(hook)
+			fmt.Fprintf(w, "Running %s...\n", hook)
+			if err := runInDir(ctx, w, path, "sh", "-c", hook); err != nil {
+				return fmt.Errorf("hook %q: %w", hook, err)
+			}
+		}
+
+		message := vars.Replace(t.Commit)
+		if message == "" {
+			message = fmt.Sprintf("Initial commit from template %s", t.Name)
+		}
+		fmt.Fprintln(w, "Creating initial commit...")
+		if err := runInDir(ctx, w, path, "git", "add", "--all"); err != nil {
+			return fmt.Errorf("git add: %w", err)
+		}
+		if err := runInDir(ctx, w, path, "git", "commit", "--quiet", "-m", message); err != nil {
+			return fmt.Errorf("git commit: %w", err)
+		}
+		fmt.Fprintf(w, "\n✓ Created %s from %s\n", name, t.Name)
+		return nil
+	}
+}
+
+// runInDir runs a command in dir, streaming its output to w.
+func runInDir(ctx context.Context, w io.Writer, dir, name string, args ...string) error {
+	cmd := groupCommand(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = colorEnv()
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return cmd.Run()
+}
+
+// openTemplates lists the templates for the project m.newProject, the step
+// between entering its name and stateSetupProjectConfirm.
+func (m model) openTemplates(name string) model {
+	m.newProject = name
+	templates, problems := loadTemplates()
+	m.templates = templates
+	m.textInput.Blur()
+	m.state = stateSelectTemplate
+	m.cursor = 0
+	if len(problems) > 0 {
+		m.message = "Skipped templates:\n" + strings.Join(problems, "\n")
+		m.messageType = "error"
+	}
+	return m
+}
+
+// templateItems lists an empty project, then the templates.
+func (m model) templateItems() []string {
+	items := []string{"Empty project (git init only)"}
+	for _, t := range m.templates {
+		items = append(items, t.label())
+	}
+	return append(items, "Back")
+}
+
+func (m model) handleSelectTemplate(selected string) (model, tea.Cmd) {
+	switch {
+	case selected == "Back":
+		return m.goBack(), nil
+	case m.cursor == 0:
+		return m.createProject(m.newProject)
+	}
+
+	t := m.templates[m.cursor-1]
+	name := m.newProject
+	path := filepath.Join(projectsDir, name)
+	if _, err := os.Stat(path); err == nil {
+		m.message = "Project already exists!"
+		m.messageType = "error"
+		return m, nil
+	}
+
+	m.outputTitle = fmt.Sprintf("New project %s from %s", name, t.Name)
+	m.runAfter = afterCreate(name, path, "from template "+t.Name)
+	m, cmd := m.startRun(createFromTemplate(t, name, path))
+	m.runAfter = nil
+	return m, cmd
+}
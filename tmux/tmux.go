@@ -0,0 +1,401 @@
+// Package tmux controls a tmux server by running the tmux command line.
+package tmux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client runs tmux commands against one server.
+type Client struct {
+	Socket string // Socket name passed as -L, empty for the default server
+}
+
+// Session is a tmux session.
+type Session struct {
+	Name     string
+	ID       string // e.g. "$3"
+	Windows  int
+	Attached int // Clients attached to the session
+	Created  time.Time
+	Activity time.Time
+	Path     string // Working directory of new windows
+}
+
+// Window is a window of a tmux session.
+type Window struct {
+	Session string
+	Index   int
+	ID      string // e.g. "@7"
+	Name    string
+	Active  bool
+	Panes   int
+}
+
+// Pane is a pane of a tmux window.
+type Pane struct {
+	Session string
+	Window  int // Index of the window
+	Index   int
+	ID      string // e.g. "%12"
+	Active  bool
+	Command string // Command running in the pane, e.g. "zsh" or "vim"
+	Path    string // Current working directory
+	PID     int
+}
+
+// Attachment is a terminal attached to a session, what tmux calls a client.
+type Attachment struct {
+	TTY      string // e.g. "/dev/pts/3"
+	Session  string
+	Term     string // e.g. "xterm-256color"
+	Width    int
+	Height   int
+	Activity time.Time
+}
+
+// Available reports whether the tmux command is installed.
+func Available() bool {
+	_, err := exec.LookPath("tmux")
+	return err == nil
+}
+
+// Inside reports whether this process runs inside a client of the server, so
+// that SwitchClient can move that client instead of attaching a new one.
+func (c Client) Inside() bool {
+	env := os.Getenv("TMUX")
+	if env == "" {
+		return false
+	}
+	// $TMUX is "<socket path>,<server pid>,<session index>"
+	socket, _, _ := strings.Cut(env, ",")
+	name := c.Socket
+	if name == "" {
+		name = "default"
+	}
+	return filepath.Base(socket) == name
+}
+
+// Sessions lists the sessions of the server. No server running means no
+// sessions rather than an error.
+func (c Client) Sessions(ctx context.Context) ([]Session, error) {
+	out, err := c.run(ctx, "list-sessions", "-F", format(
+		"session_name", "session_id", "session_windows", "session_attached",
+		"session_created", "session_activity", "session_path",
+	))
+	if err != nil {
+		if noServer(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sessions []Session
+	for _, f := range fields(out, 7) {
+		sessions = append(sessions, Session{
+			Name:     f[0],
+			ID:       f[1],
+			Windows:  atoi(f[2]),
+			Attached: atoi(f[3]),
+			Created:  unix(f[4]),
+			Activity: unix(f[5]),
+			Path:     f[6],
+		})
+	}
+	return sessions, nil
+}
+
+// HasSession reports whether the session name exists.
+func (c Client) HasSession(ctx context.Context, name string) bool {
+	_, err := c.run(ctx, "has-session", "-t", exact(name))
+	return err == nil
+}
+
+var windowFormat = format(
+	"session_name", "window_index", "window_id", "window_name", "window_active", "window_panes",
+)
+
+// Windows lists the windows of session, or of every session when session
+// is empty.
+func (c Client) Windows(ctx context.Context, session string) ([]Window, error) {
+	out, err := c.run(ctx, list("list-windows", session, windowFormat)...)
+	if err != nil {
+		if session == "" && noServer(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var windows []Window
+	for _, f := range fields(out, 6) {
+		windows = append(windows, Window{
+			Session: f[0],
+			Index:   atoi(f[1]),
+			ID:      f[2],
+			Name:    f[3],
+			Active:  f[4] == "1",
+			Panes:   atoi(f[5]),
+		})
+	}
+	return windows, nil
+}
+
+var paneFormat = format(
+	"session_name", "window_index", "pane_index", "pane_id", "pane_active",
+	"pane_current_command", "pane_current_path", "pane_pid",
+)
+
+// Panes lists the panes in every window of session, or of every session
+// when session is empty.
+func (c Client) Panes(ctx context.Context, session string) ([]Pane, error) {
+	out, err := c.run(ctx, list("list-panes", session, paneFormat)...)
+	if err != nil {
+		if session == "" && noServer(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var panes []Pane
+	for _, f := range fields(out, 8) {
+		panes = append(panes, Pane{
+			Session: f[0],
+			Window:  atoi(f[1]),
+			Index:   atoi(f[2]),
+			ID:      f[3],
+			Active:  f[4] == "1",
+			Command: f[5],
+			Path:    f[6],
+			PID:     atoi(f[7]),
+		})
+	}
+	return panes, nil
+}
+
+// Attachments lists the terminals attached to session, or to any session
+// when session is empty.
+func (c Client) Attachments(ctx context.Context, session string) ([]Attachment, error) {
+	args := []string{"list-clients", "-F", format(
+		"client_tty", "client_session", "client_termname", "client_width", "client_height", "client_activity",
+	)}
+	if session != "" {
+		args = append(args, "-t", exact(session))
+	}
+	out, err := c.run(ctx, args...)
+	if err != nil {
+		if session == "" && noServer(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var attachments []Attachment
+	for _, f := range fields(out, 6) {
+		attachments = append(attachments, Attachment{
+			TTY:      f[0],
+			Session:  f[1],
+			Term:     f[2],
+			Width:    atoi(f[3]),
+			Height:   atoi(f[4]),
+			Activity: unix(f[5]),
+		})
+	}
+	return attachments, nil
+}
+
+// NewSession creates the detached session name with its first window in
+// dir, running command instead of the default shell when one is given. It
+// returns the ID of the window's pane.
+func (c Client) NewSession(ctx context.Context, name, dir string, command ...string) (string, error) {
+	args := []string{"new-session", "-d", "-P", "-F", "#{pane_id}", "-s", name, "-c", dir}
+	return c.runID(ctx, append(args, command...)...)
+}
+
+// NewWindow adds a window in dir to session, running command instead of the
+// default shell when one is given. It returns the ID of the window's pane.
+func (c Client) NewWindow(ctx context.Context, session, dir string, command ...string) (string, error) {
+	args := []string{"new-window", "-P", "-F", "#{pane_id}", "-t", exact(session) + ":", "-c", dir}
+	return c.runID(ctx, append(args, command...)...)
+}
+
+// SplitWindow splits the pane target, starting a shell in dir in the new
+// pane. It returns the ID of the new pane.
+func (c Client) SplitWindow(ctx context.Context, target, dir string) (string, error) {
+	return c.runID(ctx, "split-window", "-P", "-F", "#{pane_id}", "-t", target, "-c", dir)
+}
+
+// RenameWindow names the window target, which stops tmux naming it after
+// the command running in it.
+func (c Client) RenameWindow(ctx context.Context, target, name string) error {
+	_, err := c.run(ctx, "rename-window", "-t", target, name)
+	return err
+}
+
+// SelectLayout arranges the panes of the window target, with a preset such
+// as "tiled" or "main-vertical" or a layout string from list-windows.
+func (c Client) SelectLayout(ctx context.Context, target, layout string) error {
+	_, err := c.run(ctx, "select-layout", "-t", target, layout)
+	return err
+}
+
+// SendKeys types line into the pane target and presses enter.
+func (c Client) SendKeys(ctx context.Context, target, line string) error {
+	if _, err := c.run(ctx, "send-keys", "-t", target, "-l", line); err != nil {
+		return err
+	}
+	_, err := c.run(ctx, "send-keys", "-t", target, "Enter")
+	return err
+}
+
+// SwitchClient moves the client this process runs in to target, a session
+// name or a window or pane of one.
+func (c Client) SwitchClient(ctx context.Context, target string) error {
+	_, err := c.run(ctx, "switch-client", "-t", exactTarget(target))
+	return err
+}
+
+// Select makes target, a window or pane ID, the current window of its
+// session and, for a pane, the active pane of its window.
+func (c Client) Select(ctx context.Context, target string) error {
+	if _, err := c.run(ctx, "select-window", "-t", target); err != nil {
+		return err
+	}
+	if strings.HasPrefix(target, "%") {
+		_, err := c.run(ctx, "select-pane", "-t", target)
+		return err
+	}
+	return nil
+}
+
+// RenameSession renames the session name to newName.
+func (c Client) RenameSession(ctx context.Context, name, newName string) error {
+	_, err := c.run(ctx, "rename-session", "-t", exact(name), newName)
+	return err
+}
+
+// ClientTTY returns the terminal of the client this process runs in, see
+// Inside.
+func (c Client) ClientTTY(ctx context.Context) (string, error) {
+	return c.runID(ctx, "display-message", "-p", "#{client_tty}")
+}
+
+// DetachClient detaches the client on the terminal tty, e.g. "/dev/pts/3",
+// leaving its session running.
+func (c Client) DetachClient(ctx context.Context, tty string) error {
+	_, err := c.run(ctx, "detach-client", "-t", tty)
+	return err
+}
+
+// KillSession ends the session name and everything running in it.
+func (c Client) KillSession(ctx context.Context, name string) error {
+	_, err := c.run(ctx, "kill-session", "-t", exact(name))
+	return err
+}
+
+// AttachCommand returns the command that attaches the terminal to target, a
+// session name or a window or pane of one. It takes over the terminal, so it
+// is run by the caller rather than the Client.
+func (c Client) AttachCommand(target string) *exec.Cmd {
+	return exec.Command("tmux", c.args("attach-session", "-t", exactTarget(target))...)
+}
+
+// runID runs a command printing a single value, such as the ID of what it
+// created.
+func (c Client) runID(ctx context.Context, args ...string) (string, error) {
+	out, err := c.run(ctx, args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// args prepends the socket option to a tmux command line.
+func (c Client) args(args ...string) []string {
+	if c.Socket != "" {
+		return append([]string{"-L", c.Socket}, args...)
+	}
+	return args
+}
+
+func (c Client) run(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "tmux", c.args(args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); msg != "" && errors.As(err, &exitErr) {
+			return out, fmt.Errorf("tmux %s: %s", args[0], msg)
+		}
+		return out, fmt.Errorf("tmux %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// list returns the command line of a list command over session, or over
+// every session when session is empty.
+func list(command, session, format string) []string {
+	args := []string{command, "-F", format}
+	switch {
+	case session == "":
+		return append(args, "-a")
+	case command == "list-panes":
+		// Every window of the session, not just the current one
+		args = append(args, "-s")
+	}
+	return append(args, "-t", exact(session))
+}
+
+// noServer reports whether err is tmux finding no server on the socket.
+func noServer(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "no server running") || strings.Contains(msg, "error connecting to")
+}
+
+// exact makes a session name a target that only matches that session, not
+// one it is a prefix or pattern of.
+func exact(name string) string {
+	return "=" + name
+}
+
+// exactTarget is exact for the session part of "session:window.pane".
+func exactTarget(target string) string {
+	if strings.HasPrefix(target, "=") || strings.HasPrefix(target, "$") ||
+		strings.HasPrefix(target, "@") || strings.HasPrefix(target, "%") {
+		return target
+	}
+	return exact(target)
+}
+
+// format joins tmux format variables into a tab-separated -F argument.
+func format(vars ...string) string {
+	for i, v := range vars {
+		vars[i] = "#{" + v + "}"
+	}
+	return strings.Join(vars, "\t")
+}
+
+// fields splits the lines of a format's output into their n fields.
+func fields(out []byte, n int) [][]string {
+	var result [][]string
+	for _, line := range strings.Split(string(out), "\n") {
+		if f := strings.Split(line, "\t"); len(f) == n {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func unix(s string) time.Time {
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
@@ -0,0 +1,180 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testClient returns a client for a tmux server of its own, killed when the
+// test ends. It skips the test when tmux isn't installed.
+func testClient(t *testing.T) Client {
+	t.Helper()
+	if !Available() {
+		t.Skip("tmux is not installed")
+	}
+	name := strings.NewReplacer("/", "-", " ", "-").Replace(t.Name())
+	c := Client{Socket: fmt.Sprintf("commandy-test-%d-%s", os.Getpid(), name)}
+	t.Cleanup(func() {
+		exec.Command("tmux", c.args("kill-server")...).Run()
+	})
+	return c
+}
+
+func TestSessionsWithoutServer(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+
+	sessions, err := c.Sessions(ctx)
+	if err != nil || sessions != nil {
+		t.Fatalf("Sessions() = %v, %v, want nil, nil", sessions, err)
+	}
+	if c.HasSession(ctx, "api") {
+		t.Error("HasSession(api) = true without a server")
+	}
+	windows, err := c.Windows(ctx, "")
+	if err != nil || windows != nil {
+		t.Errorf("Windows(\"\") = %v, %v, want nil, nil", windows, err)
+	}
+	panes, err := c.Panes(ctx, "")
+	if err != nil || panes != nil {
+		t.Errorf("Panes(\"\") = %v, %v, want nil, nil", panes, err)
+	}
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+	// tmux reports the resolved path, /private/var/... on macOS
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pane, err := c.NewSession(ctx, "api", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(pane, "%") {
+		t.Errorf("NewSession() pane = %q, want a pane ID", pane)
+	}
+	if _, err := c.NewWindow(ctx, "api", dir, "sleep", "60"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.HasSession(ctx, "api") {
+		t.Fatal("HasSession(api) = false after NewSession")
+	}
+
+	sessions, err := c.Sessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("Sessions() = %v, want 1 session", sessions)
+	}
+	s := sessions[0]
+	if s.Name != "api" || s.Windows != 2 || s.Attached != 0 || s.Created.IsZero() || !strings.HasPrefix(s.ID, "$") {
+		t.Errorf("Sessions()[0] = %+v", s)
+	}
+
+	windows, err := c.Windows(ctx, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 2 {
+		t.Fatalf("Windows(api) = %v, want 2 windows", windows)
+	}
+	if windows[1].Name != "sleep" || !windows[1].Active || windows[1].Panes != 1 {
+		t.Errorf("Windows(api)[1] = %+v, want the active sleep window", windows[1])
+	}
+
+	if _, err := c.SplitWindow(ctx, pane, dir); err != nil {
+		t.Fatal(err)
+	}
+	panes, err := c.Panes(ctx, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(panes) != 3 {
+		t.Fatalf("Panes(api) = %v, want 3 panes in both windows", panes)
+	}
+	for _, p := range panes {
+		if p.Session != "api" || p.PID == 0 || !strings.HasPrefix(p.ID, "%") {
+			t.Errorf("pane %+v", p)
+		}
+	}
+	if panes[0].ID != pane || panes[0].Path != dir {
+		t.Errorf("Panes(api)[0] = %+v, want %s in %s", panes[0], pane, dir)
+	}
+
+	if err := c.RenameSession(ctx, "api", "web"); err != nil {
+		t.Fatal(err)
+	}
+	if c.HasSession(ctx, "api") || !c.HasSession(ctx, "web") {
+		t.Error("RenameSession(api, web) didn't rename the session")
+	}
+
+	if err := c.KillSession(ctx, "web"); err != nil {
+		t.Fatal(err)
+	}
+	if c.HasSession(ctx, "web") {
+		t.Error("HasSession(web) = true after KillSession")
+	}
+	if err := c.KillSession(ctx, "web"); err == nil || !strings.Contains(err.Error(), "tmux kill-session") {
+		t.Errorf("KillSession of a missing session: err = %v, want the tmux error", err)
+	}
+}
+
+func TestExactTargets(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// tmux matches -t against name prefixes unless asked for an exact match
+	if _, err := c.NewSession(ctx, "ab", dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.NewSession(ctx, "keep", dir); err != nil {
+		t.Fatal(err)
+	}
+	if c.HasSession(ctx, "a") {
+		t.Error("HasSession(a) = true with only ab running")
+	}
+	if c.HasSession(ctx, "k*") {
+		t.Error("HasSession(k*) = true, matched as a pattern")
+	}
+	if err := c.KillSession(ctx, "a"); err == nil {
+		t.Error("KillSession(a) succeeded with only ab running")
+	}
+	if _, err := c.NewWindow(ctx, "a", dir); err == nil {
+		t.Error("NewWindow(a) succeeded with only ab running")
+	}
+	if err := c.RenameSession(ctx, "a", "b"); err == nil {
+		t.Error("RenameSession(a) succeeded with only ab running")
+	}
+	if !c.HasSession(ctx, "ab") || !c.HasSession(ctx, "keep") {
+		t.Error("sessions ab and keep didn't survive")
+	}
+}
+
+func TestExactTarget(t *testing.T) {
+	tests := []struct {
+		target, want string
+	}{
+		{"api", "=api"},
+		{"api:1.0", "=api:1.0"},
+		{"=api", "=api"},
+		{"$3", "$3"},
+		{"@7", "@7"},
+		{"%12", "%12"},
+	}
+	for _, tt := range tests {
+		if got := exactTarget(tt.target); got != tt.want {
+			t.Errorf("exactTarget(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+// maxVisits is how many of a project's most recent uses are kept for
+// frecency ranking.
+const maxVisits = 10
+
+// projectUsage is how a project has been used from commandy.
+type projectUsage struct {
+	Name     string      `json:"name"` // As listed in the project browser, e.g. "app/web"
+	Opens    int         `json:"opens"`
+	Claude   int         `json:"claude_sessions"`
+	LastUsed time.Time   `json:"last_used"`
+	Visits   []time.Time `json:"visits"` // Oldest first
+	Pinned   bool        `json:"pinned"`
+}
+
+// usageState is the contents of the state file.
+type usageState struct {
+	Projects map[string]*projectUsage `json:"projects"` // By project path
+}
+
+// recentProject is a pinned or recently used project offered at the top of
+// the menus.
+type recentProject struct {
+	name, path string
+	pinned     bool
+}
+
+// usage is the state loaded at startup, updated as projects are used.
+var usage = &usageState{Projects: map[string]*projectUsage{}}
+
+// statePath returns the location of the state file:
+// $XDG_STATE_HOME/commandy/state.json, by default under ~/.local/state.
+func statePath() string {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		stateHome = filepath.Join(os.Getenv("HOME"), ".local", "state")
+	}
+	return filepath.Join(stateHome, "commandy", "state.json")
+}
+
+// loadUsage reads the state file. The state only ranks projects, so a missing
+// or unreadable file starts over with an empty one.
+func loadUsage() *usageState {
+	u := &usageState{}
+	if data, err := os.ReadFile(statePath()); err == nil {
+		json.Unmarshal(data, u)
+	}
+	if u.Projects == nil {
+		u.Projects = map[string]*projectUsage{}
+	}
+	return u
+}
+
+// save writes the state file, replacing it in one step so that a crash or a
+// second commandy never leaves it half written.
+func (u *usageState) save() error {
+	path := statePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(u, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// updateUsage applies change to the usage of the project at path and saves
+// it. The state file is read again first so that uses recorded by other
+// commandy instances are kept.
+func updateUsage(name, path string, change func(p *projectUsage)) error {
+	u := loadUsage()
+	p := u.Projects[path]
+	if p == nil {
+		p = &projectUsage{}
+		u.Projects[path] = p
+	}
+	p.Name = name
+	change(p)
+	usage = u
+	return u.save()
+}
+
+// recordProjectUse counts an open of the project, or a Claude session when
+// claude is set. Recording is best effort: failing to save never gets in
+// the way of opening the project.
+func recordProjectUse(name, path string, claude bool) {
+	now := time.Now()
+	updateUsage(name, path, func(p *projectUsage) {
+		if claude {
+			p.Claude++
+		} else {
+			p.Opens++
+		}
+		p.LastUsed = now
+		p.Visits = append(p.Visits, now)
+		if len(p.Visits) > maxVisits {
+			p.Visits = slices.Clone(p.Visits[len(p.Visits)-maxVisits:])
+		}
+	})
+}
+
+// togglePin pins or unpins the project and reports whether it is now pinned.
+func togglePin(name, path string) (bool, error) {
+	var pinned bool
+	err := updateUsage(name, path, func(p *projectUsage) {
+		p.Pinned = !p.Pinned
+		pinned = p.Pinned
+	})
+	return pinned, err
+}
+
+// isPinned reports whether the project at path is pinned.
+func isPinned(path string) bool {
+	p := usage.Projects[path]
+	return p != nil && p.Pinned
+}
+
+// frecency ranks a project by how often and how recently it was used, the
+// way Firefox ranks history: each kept visit is weighted by its age, and the
+// average weight is scaled by the total number of uses.
+func (p *projectUsage) frecency(now time.Time) float64 {
+	if len(p.Visits) == 0 {
+		return 0
+	}
+	var weight float64
+	for _, v := range p.Visits {
+		switch age := now.Sub(v); {
+		case age < 4*time.Hour:
+			weight += 100
+		case age < 24*time.Hour:
+			weight += 70
+		case age < 7*24*time.Hour:
+			weight += 50
+		case age < 30*24*time.Hour:
+			weight += 30
+		default:
+			weight += 10
+		}
+	}
+	return float64(p.Opens+p.Claude) * weight / float64(len(p.Visits))
+}
+
+// recentProjects returns the pinned projects, by name, followed by up to n
+// of the other used projects with the highest frecency. Projects that no
+// longer exist are left out.
+func recentProjects(n int) []recentProject {
+	var pinned, used []recentProject
+	for path, p := range usage.Projects {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		switch {
+		case p.Pinned:
+			pinned = append(pinned, recentProject{name: p.Name, path: path, pinned: true})
+		case len(p.Visits) > 0:
+			used = append(used, recentProject{name: p.Name, path: path})
+		}
+	}
+	sort.Slice(pinned, func(a, b int) bool { return pinned[a].name < pinned[b].name })
+	now := time.Now()
+	sort.Slice(used, func(a, b int) bool {
+		fa, fb := usage.Projects[used[a].path].frecency(now), usage.Projects[used[b].path].frecency(now)
+		if fa != fb {
+			return fa > fb
+		}
+		return used[a].name < used[b].name
+	})
+	if len(used) > n {
+		used = used[:n]
+	}
+	return append(pinned, used...)
+}
+
+// refreshRecent reads the pinned and recent projects offered atop the main
+// menu again. It checks that each still exists, so it is run when the menu
+// is entered rather than on every render.
+func (m *model) refreshRecent() {
+	m.recent = recentProjects(cfg.RecentProjects)
+}
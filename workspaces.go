@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxWorkspaceDepth is how many directory levels below a workspace root are
+// searched for packages.
+const maxWorkspaceDepth = 5
+
+// defaultWorkspaceGlobs are where turbo and nx workspaces without package
+// manager workspaces keep their packages by convention.
+var defaultWorkspaceGlobs = []string{"apps/*", "packages/*", "libs/*"}
+
+// workspacePackages returns the packages of the workspace rooted at dir, as
+// sorted slash-separated paths relative to dir. ok is false when dir is not
+// a workspace root. Packages are found from:
+//   - npm and yarn: "workspaces" in package.json
+//   - pnpm: pnpm-workspace.yaml
+//   - turbo: turbo.json, with the package manager's workspaces
+//   - nx: nx.json, with every directory holding a project.json
+//   - Go: the use directives of go.work
+func workspacePackages(dir string) (pkgs []string, ok bool) {
+	globs, ok := packageJSONWorkspaces(dir)
+	if pnpm, found := pnpmWorkspaces(dir); found {
+		globs, ok = append(globs, pnpm...), true
+	}
+	_, turboErr := os.Stat(filepath.Join(dir, "turbo.json"))
+	_, nxErr := os.Stat(filepath.Join(dir, "nx.json"))
+	if (turboErr == nil || nxErr == nil) && len(globs) == 0 {
+		globs, ok = defaultWorkspaceGlobs, true
+	}
+
+	var found []string
+	if len(globs) > 0 || nxErr == nil {
+		walkWorkspace(dir, func(rel string) {
+			switch {
+			case nxErr == nil && hasFiles(filepath.Join(dir, rel), []string{"project.json"}):
+				found = append(found, rel)
+			case hasFiles(filepath.Join(dir, rel), []string{"package.json"}) && matchWorkspaceGlobs(globs, rel):
+				found = append(found, rel)
+			}
+		})
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "go.work")); err == nil {
+		for _, use := range goWorkUses(data) {
+			rel := path.Clean(filepath.ToSlash(use))
+			if rel != "." && !strings.HasPrefix(rel, "../") && !path.IsAbs(rel) {
+				found = append(found, rel)
+			}
+		}
+		ok = true
+	}
+
+	slices.Sort(found)
+	return slices.Compact(found), ok
+}
+
+// packageJSONWorkspaces returns the npm or yarn workspace globs of dir.
+// Yarn also accepts them as {"packages": [...]}.
+func packageJSONWorkspaces(dir string) ([]string, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, false
+	}
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if json.Unmarshal(data, &pkg) != nil || pkg.Workspaces == nil {
+		return nil, false
+	}
+	var globs []string
+	if json.Unmarshal(pkg.Workspaces, &globs) == nil {
+		return globs, true
+	}
+	var yarn struct {
+		Packages []string `json:"packages"`
+	}
+	if json.Unmarshal(pkg.Workspaces, &yarn) == nil {
+		return yarn.Packages, true
+	}
+	return nil, false
+}
+
+// pnpmWorkspaces returns the package globs of dir's pnpm-workspace.yaml.
+func pnpmWorkspaces(dir string) ([]string, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "pnpm-workspace.yaml"))
+	if err != nil {
+		return nil, false
+	}
+	var ws struct {
+		Packages []string `yaml:"packages"`
+	}
+	if yaml.Unmarshal(data, &ws) != nil {
+		return nil, false
+	}
+	return ws.Packages, true
+}
+
+// goWorkUses returns the directories named by the use directives of a
+// go.work file, in both the single-line and the block form.
+func goWorkUses(data []byte) []string {
+	var uses []string
+	block := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		line = strings.TrimSpace(line)
+		switch {
+		case block && line == ")":
+			block = false
+		case block && line != "":
+			uses = append(uses, unquoteGoWork(line))
+		case strings.HasPrefix(line, "use ") || strings.HasPrefix(line, "use\t") || strings.HasPrefix(line, "use("):
+			rest := strings.TrimSpace(strings.TrimPrefix(line, "use"))
+			if rest == "(" {
+				block = true
+			} else if rest != "" {
+				uses = append(uses, unquoteGoWork(rest))
+			}
+		}
+	}
+	return uses
+}
+
+func unquoteGoWork(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return s
+}
+
+// walkWorkspace calls fn with the slash-separated path, relative to dir, of
+// every directory below dir up to maxWorkspaceDepth, skipping node_modules
+// and hidden directories.
+func walkWorkspace(dir string, fn func(rel string)) {
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || p == dir {
+			return nil
+		}
+		if d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		rel, _ := filepath.Rel(dir, p)
+		rel = filepath.ToSlash(rel)
+		fn(rel)
+		if strings.Count(rel, "/")+1 >= maxWorkspaceDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// matchWorkspaceGlobs reports whether rel is matched by the workspace globs:
+// by at least one pattern and by none of the "!"-negated ones.
+func matchWorkspaceGlobs(globs []string, rel string) bool {
+	matched := false
+	for _, glob := range globs {
+		if negated, ok := strings.CutPrefix(glob, "!"); ok {
+			if matchGlob(negated, rel) {
+				return false
+			}
+		} else if matchGlob(glob, rel) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchGlob matches a slash-separated path against a workspace glob, where
+// "**" stands for any number of directories.
+func matchGlob(glob, rel string) bool {
+	glob = strings.TrimSuffix(strings.TrimPrefix(glob, "./"), "/")
+	return matchSegments(strings.Split(glob, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(glob, parts []string) bool {
+	if len(glob) == 0 {
+		return len(parts) == 0
+	}
+	if glob[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(glob[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(glob[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(glob[1:], parts[1:])
+}
+
+// projectTree is how the entries of a project list are shown as a tree: a
+// project inside another listed project, such as a workspace package, is
+// shown as a branch under it when it follows the parent or a sibling.
+type projectTree struct {
+	parent map[int]int // Project index to the index of the listed project containing it
+}
+
+// newProjectTree finds the parent of every project in paths.
+func newProjectTree(names, paths []string) projectTree {
+	index := make(map[string]int, len(paths))
+	for i, p := range paths {
+		index[p] = i
+	}
+	t := projectTree{parent: make(map[int]int)}
+	for i, p := range paths {
+		for dir := filepath.Dir(p); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+			if j, ok := index[dir]; ok {
+				if strings.HasPrefix(names[i], names[j]+"/") {
+					t.parent[i] = j
+				}
+				break
+			}
+		}
+	}
+	return t
+}
+
+// branch returns the tree label of the project at position pos of order, a
+// list of project indexes as shown, e.g. "├ packages/ui". shift is how far
+// the characters of the name move in the label and indent the length of
+// the tree lines before them; ok is false when the project is shown by its
+// name.
+func (t projectTree) branch(names []string, order []int, pos int) (label string, shift, indent int, ok bool) {
+	i := order[pos]
+	parent, ok := t.parent[i]
+	if !ok || pos == 0 {
+		return "", 0, 0, false
+	}
+	if prev := order[pos-1]; prev != parent && !t.hasAncestor(prev, parent) {
+		return "", 0, 0, false
+	}
+	connector := "└ "
+	for _, next := range order[pos+1:] {
+		if p, ok := t.parent[next]; ok && p == parent {
+			connector = "├ "
+			break
+		}
+		if !t.hasAncestor(next, parent) {
+			break
+		}
+	}
+	depth := 0
+	for p, ok := parent, true; ok; p, ok = t.parent[p] {
+		depth++
+	}
+	lines := strings.Repeat("  ", depth-1) + connector
+	indent = len([]rune(lines))
+	prefix := len([]rune(names[parent])) + 1
+	return lines + string([]rune(names[i])[prefix:]), indent - prefix, indent, true
+}
+
+// hasAncestor reports whether the project i is inside the project ancestor.
+func (t projectTree) hasAncestor(i, ancestor int) bool {
+	for p, ok := t.parent[i]; ok; p, ok = t.parent[p] {
+		if p == ancestor {
+			return true
+		}
+	}
+	return false
+}
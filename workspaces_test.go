@@ -0,0 +1,272 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles creates files, slash-separated paths to their contents, under
+// a new temporary directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestWorkspacePackages(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		want   []string
+		wantOK bool
+	}{
+		{
+			name: "npm",
+			files: map[string]string{
+				"package.json":                   `{"workspaces": ["packages/*", "apps/web"]}`,
+				"packages/ui/package.json":       `{}`,
+				"packages/utils/package.json":    `{}`,
+				"packages/docs/README.md":        "no package.json",
+				"apps/web/package.json":          `{}`,
+				"apps/admin/package.json":        `{}`,
+				"node_modules/left/package.json": `{}`,
+			},
+			want:   []string{"apps/web", "packages/ui", "packages/utils"},
+			wantOK: true,
+		},
+		{
+			name: "yarn object form",
+			files: map[string]string{
+				"package.json":                    `{"workspaces": {"packages": ["packages/**"], "nohoist": ["**/react"]}}`,
+				"packages/ui/package.json":        `{}`,
+				"packages/tools/cli/package.json": `{}`,
+				"packages/.cache/package.json":    `{}`,
+			},
+			want:   []string{"packages/tools/cli", "packages/ui"},
+			wantOK: true,
+		},
+		{
+			name: "pnpm with negation",
+			files: map[string]string{
+				"package.json":                 `{"name": "root"}`,
+				"pnpm-workspace.yaml":          "packages:\n  - 'packages/*'\n  - \"!packages/legacy\"\n  - tools/*\n",
+				"packages/ui/package.json":     `{}`,
+				"packages/legacy/package.json": `{}`,
+				"tools/lint/package.json":      `{}`,
+			},
+			want:   []string{"packages/ui", "tools/lint"},
+			wantOK: true,
+		},
+		{
+			name: "turbo without workspaces uses the conventional globs",
+			files: map[string]string{
+				"turbo.json":               `{}`,
+				"apps/site/package.json":   `{}`,
+				"packages/ui/package.json": `{}`,
+				"libs/core/package.json":   `{}`,
+				"scripts/package.json":     `{}`,
+			},
+			want:   []string{"apps/site", "libs/core", "packages/ui"},
+			wantOK: true,
+		},
+		{
+			name: "turbo with workspaces uses them",
+			files: map[string]string{
+				"turbo.json":                `{}`,
+				"package.json":              `{"workspaces": ["services/*"]}`,
+				"services/api/package.json": `{}`,
+				"apps/site/package.json":    `{}`,
+			},
+			want:   []string{"services/api"},
+			wantOK: true,
+		},
+		{
+			name: "nx projects",
+			files: map[string]string{
+				"nx.json":                       `{}`,
+				"apps/shop/project.json":        `{}`,
+				"tools/generators/project.json": `{}`,
+				"packages/ui/package.json":      `{}`,
+				"docs/README.md":                "no project",
+			},
+			want:   []string{"apps/shop", "packages/ui", "tools/generators"},
+			wantOK: true,
+		},
+		{
+			name: "go.work",
+			files: map[string]string{
+				"go.work":         "go 1.25\n\nuse (\n\t./api\n\t./cmd/tool // the CLI\n\t.\n\t../elsewhere\n)\n",
+				"api/go.mod":      "module api\n",
+				"cmd/tool/go.mod": "module tool\n",
+			},
+			want:   []string{"api", "cmd/tool"},
+			wantOK: true,
+		},
+		{
+			name: "not a workspace",
+			files: map[string]string{
+				"package.json":        `{"name": "app"}`,
+				"client/package.json": `{}`,
+				"server/package.json": `{}`,
+			},
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgs, ok := workspacePackages(writeFiles(t, tt.files))
+			if ok != tt.wantOK || !reflect.DeepEqual(pkgs, tt.want) {
+				t.Errorf("workspacePackages() = %q, %v, want %q, %v", pkgs, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWorkspacePackagesDepth(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"package.json":             `{"workspaces": ["**"]}`,
+		"a/b/c/d/e/package.json":   `{}`,
+		"a/b/c/d/e/f/package.json": `{}`,
+	})
+	pkgs, _ := workspacePackages(dir)
+	if want := []string{"a/b/c/d/e"}; !reflect.DeepEqual(pkgs, want) {
+		t.Errorf("workspacePackages() = %q, want %q", pkgs, want)
+	}
+}
+
+func TestGoWorkUses(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"single", "go 1.25\n\nuse ./api\nuse ./web\n", []string{"./api", "./web"}},
+		{"block", "go 1.25\n\nuse (\n\t./api\n\n\t./web // front end\n)\n", []string{"./api", "./web"}},
+		{"quoted", "use \"./my api\"\nuse (\n\t`./raw`\n)\n", []string{"./my api", "./raw"}},
+		{"comments", "// use ./old\nuse ./api // use ./other\n", []string{"./api"}},
+		{"not use", "go 1.25\nuser ./api\nreplace x => ./y\n", nil},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		if got := goWorkUses([]byte(tt.data)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: goWorkUses() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob, rel string
+		want      bool
+	}{
+		{"packages/*", "packages/ui", true},
+		{"./packages/*/", "packages/ui", true},
+		{"packages/*", "packages/ui/src", false},
+		{"packages/*", "packages", false},
+		{"apps/web", "apps/web", true},
+		{"apps/web", "apps/webapp", false},
+		{"packages/**", "packages/ui", true},
+		{"packages/**", "packages/tools/cli", true},
+		{"packages/**", "packages", true},
+		{"**/cli", "packages/tools/cli", true},
+		{"**/cli", "cli", true},
+		{"**/cli", "packages/cli/src", false},
+		{"packages/**/ui", "packages/web/shared/ui", true},
+		{"packages/ui-*", "packages/ui-kit", true},
+		{"packages/[ab]*", "packages/core", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.glob, tt.rel); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.glob, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestMatchWorkspaceGlobs(t *testing.T) {
+	globs := []string{"packages/**", "!packages/legacy", "!**/fixtures"}
+	tests := map[string]bool{
+		"packages/ui":             true,
+		"packages/legacy":         false,
+		"packages/tools/fixtures": false,
+		"packages/legacy/ui":      true,
+		"apps/web":                false,
+	}
+	for rel, want := range tests {
+		if got := matchWorkspaceGlobs(globs, rel); got != want {
+			t.Errorf("matchWorkspaceGlobs(%q, %q) = %v, want %v", globs, rel, got, want)
+		}
+	}
+	if matchWorkspaceGlobs([]string{"!packages/legacy"}, "packages/ui") {
+		t.Error("only negated globs matched packages/ui")
+	}
+}
+
+func TestProjectTree(t *testing.T) {
+	names := []string{"web", "web/apps/site", "web/packages/ui", "api", "web/apps/site/e2e", "work/tools"}
+	paths := []string{
+		"/p/web", "/p/web/apps/site", "/p/web/packages/ui", "/p/api", "/p/web/apps/site/e2e",
+		"/p/web/tools", // Listed from another root, not a package of web
+	}
+	tree := newProjectTree(names, paths)
+
+	type branch struct {
+		label         string
+		shift, indent int
+		ok            bool
+	}
+	tests := []struct {
+		name  string
+		order []int
+		want  []branch
+	}{
+		{
+			name:  "all",
+			order: []int{0, 1, 4, 2, 3, 5},
+			want: []branch{
+				{},
+				{"├ apps/site", -2, 2, true},
+				{"  └ e2e", -10, 4, true},
+				{"└ packages/ui", -2, 2, true},
+				{},
+				{},
+			},
+		},
+		{
+			name:  "package first",
+			order: []int{2, 0},
+			want:  []branch{{}, {}},
+		},
+		{
+			name:  "package apart from its root",
+			order: []int{0, 3, 2},
+			want:  []branch{{}, {}, {}},
+		},
+		{
+			name:  "nested package under a sibling",
+			order: []int{0, 2, 4},
+			want:  []branch{{}, {"└ packages/ui", -2, 2, true}, {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for pos := range tt.order {
+				var got branch
+				got.label, got.shift, got.indent, got.ok = tree.branch(names, tt.order, pos)
+				if got != tt.want[pos] {
+					t.Errorf("branch(%v, %d) = %+v, want %+v", tt.order, pos, got, tt.want[pos])
+				}
+			}
+		})
+	}
+}
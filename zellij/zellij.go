@@ -0,0 +1,150 @@
+// Package zellij controls zellij sessions by running the zellij command
+// line.
+package zellij
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Session is a running zellij session.
+type Session struct {
+	Name    string
+	Created time.Time // Zero when the age couldn't be read
+	Current bool      // The session this process runs in
+}
+
+// Available reports whether the zellij command is installed.
+func Available() bool {
+	_, err := exec.LookPath("zellij")
+	return err == nil
+}
+
+// Inside reports whether this process runs inside a zellij session.
+func Inside() bool {
+	return os.Getenv("ZELLIJ") != ""
+}
+
+// Sessions lists the running sessions. Exited sessions, which zellij keeps
+// to resurrect, are left out.
+func Sessions(ctx context.Context) ([]Session, error) {
+	out, err := run(ctx, "", "list-sessions", "--no-formatting")
+	if err != nil {
+		if strings.Contains(err.Error(), "No active zellij sessions") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sessions []Session
+	for _, line := range strings.Split(string(out), "\n") {
+		// name [Created 2h 5m 3s ago] (current)
+		name, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if name == "" || strings.Contains(rest, "EXITED") {
+			continue
+		}
+		s := Session{Name: name, Current: strings.Contains(rest, "(current)")}
+		if age, ok := parseAge(rest); ok {
+			s.Created = time.Now().Add(-age)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// HasSession reports whether the session name is running.
+func HasSession(ctx context.Context, name string) bool {
+	sessions, _ := Sessions(ctx)
+	for _, s := range sessions {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSession starts the session name in the background, in dir.
+func NewSession(ctx context.Context, name, dir string) error {
+	_, err := run(ctx, dir, "attach", "--create-background", name)
+	return err
+}
+
+// Run runs command in a new pane of session, in dir.
+func Run(ctx context.Context, session, dir string, command ...string) error {
+	args := []string{"--session", session, "run", "--cwd", dir, "--"}
+	_, err := run(ctx, dir, append(args, command...)...)
+	return err
+}
+
+// KillSession ends the session name.
+func KillSession(ctx context.Context, name string) error {
+	_, err := run(ctx, "", "kill-session", name)
+	return err
+}
+
+// AttachCommand returns the command that attaches the terminal to the
+// session name. It takes over the terminal, so it is run by the caller.
+func AttachCommand(name string) *exec.Cmd {
+	return exec.Command("zellij", "attach", name)
+}
+
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "zellij", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			// list-sessions reports having none on stdout
+			msg = strings.TrimSpace(string(out))
+		}
+		if msg != "" && errors.As(err, &exitErr) {
+			return out, fmt.Errorf("zellij %s: %s", args[0], msg)
+		}
+		return out, fmt.Errorf("zellij %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// parseAge reads the age in "[Created 1day 2h 5m 3s ago]".
+func parseAge(s string) (time.Duration, bool) {
+	_, s, ok := strings.Cut(s, "[Created ")
+	if !ok {
+		return 0, false
+	}
+	s, _, ok = strings.Cut(s, " ago]")
+	if !ok {
+		return 0, false
+	}
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"days", 24 * time.Hour}, {"day", 24 * time.Hour}, {"d", 24 * time.Hour},
+		{"h", time.Hour}, {"m", time.Minute}, {"s", time.Second},
+	}
+	var age time.Duration
+	for _, field := range strings.Fields(s) {
+		found := false
+		for _, u := range units {
+			if n, err := strconv.Atoi(strings.TrimSuffix(field, u.suffix)); err == nil && strings.HasSuffix(field, u.suffix) {
+				age += time.Duration(n) * u.unit
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return age, true
+}